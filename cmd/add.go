@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Log a session after the fact",
+	Long: `Logs a deep work session that happened away from the terminal.
+
+Give the session's boundaries with --start and --end, or describe it relative to
+now with --duration and --ago. Times can be HH:MM (today, or yesterday if that
+time hasn't come yet), YYYY-MM-DD HH:MM, or a duration meaning "that long ago".
+Sessions that overlap one already in the log are refused unless --force is given.
+
+Example:
+  flow add --tag "Reading" --start 09:00 --end 11:15
+  flow add --tag "Whiteboarding" --duration 2h --ago 3h`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tag, project, labels := resolveTag(cmd)
+		startStr, _ := cmd.Flags().GetString("start")
+		endStr, _ := cmd.Flags().GetString("end")
+		durationStr, _ := cmd.Flags().GetString("duration")
+		agoStr, _ := cmd.Flags().GetString("ago")
+		pausedStr, _ := cmd.Flags().GetString("paused")
+		force, _ := cmd.Flags().GetBool("force")
+
+		startTime, endTime, err := resolveAddRange(time.Now(), startStr, endStr, durationStr, agoStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var paused time.Duration
+		if pausedStr != "" {
+			paused, err = time.ParseDuration(pausedStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid duration format for --paused: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		entry, overlaps, err := core.AddLogEntry(core.LogEntry{
+			Tag:         tag,
+			StartTime:   startTime,
+			EndTime:     endTime,
+			TotalPaused: paused,
+			Project:     project,
+			Labels:      labels,
+		}, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			printOverlaps(overlaps)
+			if len(overlaps) > 0 {
+				fmt.Fprintf(os.Stderr, "Use --force to log it anyway.\n")
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("📝 Logged session: %s\n", entry.Tag)
+		fmt.Printf("%s %s-%s %s\n",
+			entry.EndTime.Format("Jan 2"),
+			entry.StartTime.Format("15:04"),
+			entry.EndTime.Format("15:04"),
+			core.FormatDuration(entry.Duration))
+	},
+}
+
+// printOverlaps lists logged sessions that clash with a new one
+func printOverlaps(overlaps []core.LogEntry) {
+	for _, overlap := range overlaps {
+		fmt.Fprintf(os.Stderr, "  [%s] %s %s-%s %s\n",
+			core.ShortID(overlap.ID),
+			overlap.EndTime.Format("Jan 2"),
+			overlap.StartTime.Format("15:04"),
+			overlap.EndTime.Format("15:04"),
+			overlap.Tag)
+	}
+}
+
+// resolveAddRange works out a session's start and end from the combination of
+// flags given to 'flow add'.
+func resolveAddRange(now time.Time, startStr, endStr, durationStr, agoStr string) (time.Time, time.Time, error) {
+	var startTime, endTime time.Time
+	var duration time.Duration
+	var err error
+
+	if durationStr != "" {
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil || duration <= 0 {
+			return startTime, endTime, fmt.Errorf("invalid duration %q for --duration", durationStr)
+		}
+	}
+	if startStr != "" && endStr != "" && durationStr != "" {
+		return startTime, endTime, fmt.Errorf("use at most two of --start, --end and --duration")
+	}
+	if endStr != "" && agoStr != "" {
+		return startTime, endTime, fmt.Errorf("--end and --ago cannot be combined")
+	}
+
+	if startStr != "" {
+		if startTime, err = core.ParsePastTime(startStr, now); err != nil {
+			return startTime, endTime, fmt.Errorf("--start: %w", err)
+		}
+	}
+
+	switch {
+	case endStr != "":
+		if endTime, err = core.ParsePastTime(endStr, now); err != nil {
+			return startTime, endTime, fmt.Errorf("--end: %w", err)
+		}
+	case agoStr != "":
+		ago, err := time.ParseDuration(agoStr)
+		if err != nil || ago < 0 {
+			return startTime, endTime, fmt.Errorf("invalid duration %q for --ago", agoStr)
+		}
+		endTime = now.Add(-ago)
+	case startStr != "" && duration > 0:
+		endTime = startTime.Add(duration)
+	default:
+		endTime = now
+	}
+
+	if startStr == "" {
+		if duration == 0 {
+			return startTime, endTime, fmt.Errorf("specify --start or --duration")
+		}
+		startTime = endTime.Add(-duration)
+	}
+
+	return startTime, endTime, nil
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringP("tag", "t", "Deep Work", "A description of the work session")
+	addCmd.Flags().String("start", "", "When the session started (HH:MM, YYYY-MM-DD HH:MM or e.g. '3h' ago)")
+	addCmd.Flags().String("end", "", "When the session ended (defaults to now)")
+	addCmd.Flags().String("duration", "", "How long the session lasted (e.g., '2h')")
+	addCmd.Flags().String("ago", "", "How long ago the session ended (e.g., '3h')")
+	addCmd.Flags().String("paused", "", "Time spent paused during the session (e.g., '10m')")
+	addSessionProjectFlags(addCmd)
+	addCmd.Flags().Bool("force", false, "Log the session even if it overlaps existing ones")
+}
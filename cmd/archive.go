@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Compress or delete old monthly log files",
+	Long: `Compresses the monthly log files of months that ended more than --older-than
+ago with gzip. Archived months stay part of your history: log, export,
+insights and the other commands read them transparently, and editing or
+deleting an archived session unpacks its month again.
+
+With --delete-older-than, the files of months that ended longer ago than that
+are deleted instead. Deleted sessions can't be recovered, so check with
+--dry-run first.
+
+Without flags, the retention policy in config.yml is applied. The policy is
+also applied automatically whenever a session is logged.
+
+Examples:
+  flow archive --older-than 12mo
+  flow archive --older-than 1y --delete-older-than 5y --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		deleteOlderThan, _ := cmd.Flags().GetString("delete-older-than")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if olderThan == "" && deleteOlderThan == "" {
+			config, err := core.LoadConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+			if config.Retention.IsZero() {
+				fmt.Fprintln(os.Stderr, "Error: give --older-than or --delete-older-than, or set a retention policy in config.yml")
+				os.Exit(1)
+			}
+			olderThan, deleteOlderThan = config.Retention.ArchiveAfter, config.Retention.DeleteAfter
+		}
+
+		now := time.Now()
+		var archiveBefore, deleteBefore time.Time
+		var err error
+		if olderThan != "" {
+			if archiveBefore, err = core.ParseAge(olderThan, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --older-than: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if deleteOlderThan != "" {
+			if deleteBefore, err = core.ParseAge(deleteOlderThan, now); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --delete-older-than: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		defer lockSession()()
+
+		report, err := core.ArchiveLogs(archiveBefore, deleteBefore, dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error archiving logs: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(report.Archived) == 0 && len(report.Deleted) == 0 {
+			fmt.Println("✅ Nothing to archive.")
+			return
+		}
+		verb, deleted := "Archived", "Deleted"
+		if dryRun {
+			verb, deleted = "Would archive", "Would delete"
+		}
+		for _, file := range report.Archived {
+			if dryRun {
+				fmt.Printf("  %s %s (%s)\n", verb, file.Name, formatSize(file.Size))
+			} else {
+				fmt.Printf("  %s %s (%s → %s)\n", verb, file.Name, formatSize(file.Size), formatSize(file.Archived))
+			}
+		}
+		for _, file := range report.Deleted {
+			fmt.Printf("  %s %s (%s)\n", deleted, file.Name, formatSize(file.Size))
+		}
+		if dryRun {
+			fmt.Printf("\n%sDry run: nothing was changed.%s\n", core.Dim, core.Reset)
+		}
+	},
+}
+
+// formatSize formats a file size in bytes for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.Flags().String("older-than", "", "Compress the files of months that ended longer ago than this (e.g., '12mo', '2y')")
+	archiveCmd.Flags().String("delete-older-than", "", "Delete the files of months that ended longer ago than this")
+	archiveCmd.Flags().Bool("dry-run", false, "Show what would be archived or deleted without changing anything")
+}
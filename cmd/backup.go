@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Bundle your sessions, logs, config and hooks into one archive",
+	Long: `Writes a gzipped tar archive holding the active session, every monthly log
+(archived ones included), the SQLite database if you use it, config.yml and
+your hooks. A manifest in the archive lists each file with its size and
+SHA-256 checksum, so 'flow restore' can check the backup is complete and
+intact before touching anything.
+
+Without --output, the backup is written to the current directory as
+flow-backup-YYYYMMDD-HHMMSS.tar.gz.
+
+Examples:
+  flow backup
+  flow backup --output ~/Dropbox/flow.tar.gz`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = core.DefaultBackupName(time.Now())
+		}
+
+		defer lockSession()()
+
+		manifest, err := core.CreateBackup(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating backup: %v\n", err)
+			os.Exit(1)
+		}
+		var size int64
+		for _, file := range manifest.Files {
+			size += file.Size
+		}
+		fmt.Printf("✅ Backed up %d files (%s) to %s\n", len(manifest.Files), formatSize(size), output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.Flags().StringP("output", "o", "", "File to write the backup to")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Discard the active session without logging it",
+	Long: `Discards the current session without adding it to your log.
+
+Use this when you started a session by mistake. The cancelled session is kept
+in an audit log, so 'flow cancel --undo' can bring it back. Pass --no-audit to
+discard it for good. For pomodoro sessions, work phases that already finished
+stay in the log; only the current phase is discarded.
+
+Example:
+  flow cancel
+  flow cancel --undo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		undo, _ := cmd.Flags().GetBool("undo")
+		noAudit, _ := cmd.Flags().GetBool("no-audit")
+
+		defer lockSession()()
+
+		if undo {
+			record, err := core.UndoCancel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("🌊 Restored session: %s\n", record.Session.Tag)
+			fmt.Printf("Cancelled %s ago; that time counts towards the session.\n",
+				core.FormatDuration(time.Since(record.CancelledAt)))
+			return
+		}
+
+		if !core.SessionExists() {
+			fmt.Printf("🌊 No active session to cancel.\n")
+			return
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
+		parent, err := core.CancelSession(session, !noAudit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cancelling session: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🗑️  Cancelled session: %s\n", session.Tag)
+		if noAudit || parent != nil {
+			fmt.Printf("Nothing was logged.\n")
+		} else {
+			fmt.Printf("Nothing was logged. Use 'flow cancel --undo' to restore it.\n")
+		}
+		core.RunSessionHook(session, "on_cancel", session.Tag)
+		returnTo(parent, session.Tag)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cancelCmd)
+	cancelCmd.Flags().Bool("undo", false, "Restore the most recently cancelled session")
+	cancelCmd.Flags().Bool("no-audit", false, "Don't keep a record of the cancelled session")
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/e6a5/flow/core"
 	"github.com/spf13/cobra"
 )
 
@@ -65,6 +66,15 @@ PowerShell:
 	},
 }
 
+// completePresets offers the names of the presets defined in config.yml
+func completePresets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	config, err := core.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return config.PresetNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
 func init() {
 	rootCmd.AddCommand(completionCmd)
 }
@@ -8,12 +8,15 @@ import (
 var dashboardCmd = &cobra.Command{
 	Use:   "dashboard",
 	Short: "Show a yearly contribution graph of your focus sessions",
-	Long:  `Visualizes your deep work history over the last year, similar to a GitHub contribution graph.`,
+	Long: `Visualizes your deep work history over the last year, similar to a GitHub contribution graph.
+Use --since, --until or --range to show another period; the graph covers up to a year ending with it.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		core.HandleDashboard()
+		core.HandleDashboard(statusFilter(cmd), dateRange(cmd))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(dashboardCmd)
+	addStatusFlags(dashboardCmd)
+	addDateRangeFlags(dashboardCmd)
 }
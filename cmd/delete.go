@@ -12,16 +12,31 @@ import (
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete [id]",
 	Short: "Deletes a session",
 	Long: `Deletes a session from the log.
 
-This command interactively lists your recent sessions and allows you to select one to delete.
+Without arguments, this command interactively lists your recent sessions and allows you to select one to delete.
+Pass a session ID (as shown by 'flow log') to delete that session directly.
 You will be asked to confirm before the session is permanently removed.
 
 Example:
-  flow delete`,
+  flow delete
+  flow delete 3f9k2m7q`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		scanner := bufio.NewScanner(os.Stdin)
+
+		if len(args) == 1 {
+			entry, err := core.FindLogEntry(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			confirmDelete(scanner, entry)
+			return
+		}
+
 		sessions, err := core.GetRecentSessions(10)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting recent sessions: %v\n", err)
@@ -35,11 +50,10 @@ Example:
 
 		fmt.Println("Select a session to delete:")
 		for i, session := range sessions {
-			fmt.Printf("%d: %s - %s (%s)\n", i+1, session.StartTime.Format("2006-01-02 15:04"), session.Tag, session.Duration)
+			fmt.Printf("%d: [%s] %s - %s (%s)\n", i+1, core.ShortID(session.ID), session.StartTime.Format("2006-01-02 15:04"), session.Tag, session.Duration)
 		}
 
 		fmt.Print("Enter the number of the session to delete (or 0 to cancel): ")
-		scanner := bufio.NewScanner(os.Stdin)
 		scanner.Scan()
 		input := scanner.Text()
 
@@ -54,25 +68,28 @@ Example:
 			return
 		}
 
-		sessionToDelete := sessions[choice-1]
+		confirmDelete(scanner, sessions[choice-1])
+	},
+}
 
-		fmt.Printf("\nYou have selected to delete the following session:\n")
-		fmt.Printf("%s - %s (%s)\n", sessionToDelete.StartTime.Format("2006-01-02 15:04"), sessionToDelete.Tag, sessionToDelete.Duration)
-		fmt.Print("Are you sure you want to delete this session? (y/N) ")
+// confirmDelete asks the user to confirm and then removes the entry from the log
+func confirmDelete(scanner *bufio.Scanner, sessionToDelete core.LogEntry) {
+	fmt.Printf("\nYou have selected to delete the following session:\n")
+	fmt.Printf("[%s] %s - %s (%s)\n", core.ShortID(sessionToDelete.ID), sessionToDelete.StartTime.Format("2006-01-02 15:04"), sessionToDelete.Tag, sessionToDelete.Duration)
+	fmt.Print("Are you sure you want to delete this session? (y/N) ")
 
-		scanner.Scan()
-		confirmation := scanner.Text()
+	scanner.Scan()
+	confirmation := scanner.Text()
 
-		if strings.ToLower(confirmation) == "y" {
-			if err := core.DeleteLogEntry(sessionToDelete); err != nil {
-				fmt.Fprintf(os.Stderr, "Error deleting session: %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Println("Session deleted.")
-		} else {
-			fmt.Println("Operation cancelled.")
+	if strings.ToLower(confirmation) == "y" {
+		if err := core.DeleteLogEntry(sessionToDelete); err != nil {
+			fmt.Fprintf(os.Stderr, "Error deleting session: %v\n", err)
+			os.Exit(1)
 		}
-	},
+		fmt.Println("Session deleted.")
+	} else {
+		fmt.Println("Operation cancelled.")
+	}
 }
 
 func init() {
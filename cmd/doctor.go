@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your data for problems and repair them",
+	Long: `Checks the configuration, the active session and the session log for problems
+that flow otherwise works around silently:
+
+  - lines that aren't valid log entries
+  - entries filed in the wrong monthly file
+  - duplicate entries
+  - sessions with no duration, or a duration that doesn't match their times
+  - sessions that overlap
+
+With --fix, the problems that can be repaired safely are: malformed lines are
+moved to the quarantine directory, misfiled entries are moved to the right
+month, exact duplicates are removed and mismatched durations are recomputed.
+The data directory is backed up first. Other problems are left for you to
+resolve with 'flow edit' or 'flow delete'.
+
+Examples:
+  flow doctor
+  flow doctor --fix`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, _ := cmd.Flags().GetBool("fix")
+
+		defer lockSession()()
+
+		report, err := core.CheckData(fix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking data: %v\n", err)
+			if report.Backup != "" {
+				fmt.Fprintf(os.Stderr, "Your data was backed up to %s\n", report.Backup)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("🩺 Checked %d entries in %d log files.\n\n", report.Entries, report.Files)
+		if len(report.Problems) == 0 {
+			fmt.Println("✅ No problems found.")
+			return
+		}
+
+		for _, problem := range report.Problems {
+			mark := "✗"
+			if problem.Fixed {
+				mark = "✓"
+			}
+			fmt.Printf("  %s %-10s %s%s%s  %s\n", mark, problem.Kind, core.Gray, problem.Where, core.Reset, problem.Message)
+		}
+		fmt.Println()
+
+		unresolved := report.Unresolved()
+		switch {
+		case fix && report.Fixable() > 0:
+			fmt.Printf("✅ Fixed %d problems.", report.Fixable())
+			if unresolved > 0 {
+				fmt.Printf(" %d need your attention.", unresolved)
+			}
+			fmt.Println()
+			if report.Quarantine != "" {
+				fmt.Printf("Unreadable data was moved to %s\n", report.Quarantine)
+			}
+			fmt.Printf("%sA backup of your previous data is in %s%s\n", core.Dim, report.Backup, core.Reset)
+		case report.Fixable() > 0:
+			fmt.Printf("Found %d problems; %d can be repaired with 'flow doctor --fix'.\n", len(report.Problems), report.Fixable())
+		default:
+			fmt.Printf("Found %d problems that need your attention.\n", len(report.Problems))
+		}
+
+		if unresolved > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().Bool("fix", false, "Repair the problems that can be fixed safely")
+}
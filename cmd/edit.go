@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+)
+
+const editTimeLayout = "2006-01-02 15:04:05"
+
+var editCmd = &cobra.Command{
+	Use:   "edit [id]",
+	Short: "Correct a logged session",
+	Long: `Changes the tag, start and end times or paused time of a logged session.
+
+Pick the session by ID (as shown by 'flow log') or use --last for the most recent one.
+Without any change flags, the session is opened in $EDITOR as YAML (JSON works too).
+A new tag sets the project and labels as well, as in 'acme/web Deploy #ops'.
+The duration is recomputed from the new times, and the session is moved to the
+right monthly log file if its end time changes month.
+
+Example:
+  flow edit --last --tag "Code review"
+  flow edit 3f9k2m7q --start 09:00 --end 11:15
+  flow edit --last`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		last, _ := cmd.Flags().GetBool("last")
+
+		var entry core.LogEntry
+		switch {
+		case len(args) == 1:
+			var err error
+			entry, err = core.FindLogEntry(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		case last:
+			sessions, err := core.GetRecentSessions(1)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting recent sessions: %v\n", err)
+				os.Exit(1)
+			}
+			if len(sessions) == 0 {
+				fmt.Println("No sessions to edit.")
+				return
+			}
+			entry = sessions[0]
+		default:
+			fmt.Fprintf(os.Stderr, "Error: specify a session ID or use --last\n")
+			os.Exit(1)
+		}
+
+		var updated core.LogEntry
+		var err error
+		if cmd.Flags().Changed("tag") || cmd.Flags().Changed("start") ||
+			cmd.Flags().Changed("end") || cmd.Flags().Changed("paused") {
+			updated, err = applyEditFlags(cmd, entry)
+		} else {
+			updated, err = editInEditor(entry)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		stored, err := core.EditLogEntry(entry, updated)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating session: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✏️  Updated session: %s\n", stored.Tag)
+		fmt.Printf("%s %s-%s %s\n",
+			stored.EndTime.Format("Jan 2"),
+			stored.StartTime.Format("15:04"),
+			stored.EndTime.Format("15:04"),
+			core.FormatDuration(stored.Duration))
+	},
+}
+
+// applyEditFlags returns a copy of entry with the values given on the command
+// line. A new tag sets the project and labels too, as it does for 'flow start'.
+func applyEditFlags(cmd *cobra.Command, entry core.LogEntry) (core.LogEntry, error) {
+	if cmd.Flags().Changed("tag") {
+		tag, _ := cmd.Flags().GetString("tag")
+		entry.Tag, entry.Project, entry.Labels = core.ResolveTag(tag, "", nil)
+	}
+	if cmd.Flags().Changed("start") {
+		value, _ := cmd.Flags().GetString("start")
+		t, err := core.ParseClockTime(value, entry.StartTime.Local())
+		if err != nil {
+			return entry, fmt.Errorf("--start: %w", err)
+		}
+		entry.StartTime = t
+	}
+	if cmd.Flags().Changed("end") {
+		value, _ := cmd.Flags().GetString("end")
+		t, err := core.ParseClockTime(value, entry.EndTime.Local())
+		if err != nil {
+			return entry, fmt.Errorf("--end: %w", err)
+		}
+		entry.EndTime = t
+	}
+	if cmd.Flags().Changed("paused") {
+		value, _ := cmd.Flags().GetString("paused")
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return entry, fmt.Errorf("--paused: %w", err)
+		}
+		entry.TotalPaused = d
+	}
+	return entry, nil
+}
+
+// editableEntry is the subset of a log entry presented in the editor
+type editableEntry struct {
+	Tag     string   `yaml:"tag" json:"tag"`
+	Project string   `yaml:"project" json:"project"`
+	Labels  []string `yaml:"labels" json:"labels"`
+	Start   string   `yaml:"start" json:"start"`
+	End     string   `yaml:"end" json:"end"`
+	Paused  string   `yaml:"paused" json:"paused"`
+}
+
+// editInEditor opens the entry in the user's editor and returns the result
+func editInEditor(entry core.LogEntry) (core.LogEntry, error) {
+	editable := editableEntry{
+		Tag:     entry.Tag,
+		Project: entry.Project,
+		Labels:  entry.Labels,
+		Start:   entry.StartTime.Local().Format(editTimeLayout),
+		End:     entry.EndTime.Local().Format(editTimeLayout),
+		Paused:  entry.TotalPaused.String(),
+	}
+	data, err := yaml.Marshal(editable)
+	if err != nil {
+		return entry, err
+	}
+
+	tempFile, err := os.CreateTemp("", "flow-edit-*.yml")
+	if err != nil {
+		return entry, err
+	}
+	defer func() {
+		_ = os.Remove(tempFile.Name()) // Best-effort cleanup
+	}()
+
+	header := "# Edit the session and save to apply. Times are YYYY-MM-DD HH:MM:SS (local time).\n" +
+		"# The duration is recomputed as end - start - paused. A project or #labels\n" +
+		"# written into the tag are filed like those of 'flow start'.\n"
+	if _, err := tempFile.WriteString(header + string(data)); err != nil {
+		_ = tempFile.Close()
+		return entry, err
+	}
+	if err := tempFile.Close(); err != nil {
+		return entry, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Allow editors configured with arguments, such as "code --wait"
+	parts := strings.Fields(editor)
+	editorCmd := exec.Command(parts[0], append(parts[1:], tempFile.Name())...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return entry, fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err = os.ReadFile(tempFile.Name())
+	if err != nil {
+		return entry, err
+	}
+
+	var edited editableEntry
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		return entry, fmt.Errorf("could not parse edited session: %w", err)
+	}
+
+	// A project written into the tag wins over the project field
+	project := edited.Project
+	if _, tagProject, _ := core.ParseTag(edited.Tag); tagProject != "" {
+		project = tagProject
+	}
+	entry.Tag, entry.Project, entry.Labels = core.ResolveTag(edited.Tag, project, edited.Labels)
+	if entry.StartTime, err = core.ParseClockTime(edited.Start, entry.StartTime.Local()); err != nil {
+		return entry, fmt.Errorf("start: %w", err)
+	}
+	if entry.EndTime, err = core.ParseClockTime(edited.End, entry.EndTime.Local()); err != nil {
+		return entry, fmt.Errorf("end: %w", err)
+	}
+	if edited.Paused == "" {
+		entry.TotalPaused = 0
+	} else if entry.TotalPaused, err = time.ParseDuration(edited.Paused); err != nil {
+		return entry, fmt.Errorf("paused: %w", err)
+	}
+	return entry, nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().Bool("last", false, "Edit the most recently logged session")
+	editCmd.Flags().StringP("tag", "t", "", "New tag for the session")
+	editCmd.Flags().String("start", "", "New start time (HH:MM or YYYY-MM-DD HH:MM)")
+	editCmd.Flags().String("end", "", "New end time (HH:MM or YYYY-MM-DD HH:MM)")
+	editCmd.Flags().String("paused", "", "New total paused time (e.g., '15m')")
+}
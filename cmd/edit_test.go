@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/e6a5/flow/core"
+)
+
+func TestEditTagSetsProject(t *testing.T) {
+	end := time.Date(2025, 3, 4, 10, 0, 0, 0, time.Local)
+	entry := core.LogEntry{Tag: "Deploy", Project: "a/b", Labels: []string{"ops"}, StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour}
+
+	if err := editCmd.Flags().Set("tag", "client/web Deploy #release"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = editCmd.Flags().Set("tag", "")
+		editCmd.Flags().Lookup("tag").Changed = false
+	})
+	updated, err := applyEditFlags(editCmd, entry)
+	if err != nil || updated.Tag != "Deploy" || updated.Project != "client/web" || !slices.Equal(updated.Labels, []string{"release"}) {
+		t.Errorf("applyEditFlags() = %q %q %v, %v", updated.Tag, updated.Project, updated.Labels, err)
+	}
+
+	// A project written into the tag in the editor wins over the project field
+	editor := filepath.Join(t.TempDir(), "editor")
+	script := "#!/bin/sh\nsed 's|^tag: .*|tag: client/web Deploy|' \"$1\" > \"$1.new\" && mv \"$1.new\" \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+	updated, err = editInEditor(entry)
+	if err != nil || updated.Tag != "Deploy" || updated.Project != "client/web" || !slices.Equal(updated.Labels, []string{"ops"}) {
+		t.Errorf("editInEditor() = %q %q %v, %v", updated.Tag, updated.Project, updated.Labels, err)
+	}
+
+	// Saving without changes keeps the project and labels
+	t.Setenv("VISUAL", "true")
+	updated, err = editInEditor(entry)
+	if err != nil || updated.Tag != "Deploy" || updated.Project != "a/b" || !slices.Equal(updated.Labels, []string{"ops"}) {
+		t.Errorf("editInEditor() unchanged = %q %q %v, %v", updated.Tag, updated.Project, updated.Labels, err)
+	}
+}
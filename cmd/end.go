@@ -12,58 +12,122 @@ import (
 var endCmd = &cobra.Command{
 	Use:   "end",
 	Short: "Complete the session and log it",
-	Long:  `Completes the current deep work session, logs the total focus time, and cleans up the session file.`,
+	Long: `Completes the current deep work session, logs the total focus time, and cleans up the session file.
+
+Use --at to end the session at an earlier time, for example if you forgot to run 'end' when you stopped.
+
+Example:
+  flow end --at 17:30`,
 	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
 		if !core.SessionExists() {
 			fmt.Printf("🌊 No active session to end.\n")
 			return
 		}
 
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
 		session, err := core.LoadSession()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
 			os.Exit(1)
 		}
 
+		// Idle time at the end of the session isn't work
+		applyIdleTimeout(&session, config)
+
+		// Catch a pomodoro session up first; it may already have completed
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
 		endTime := time.Now()
-		totalDuration := time.Since(session.StartTime) - session.TotalPaused
 		if session.IsPaused {
-			totalDuration = session.PausedAt.Sub(session.StartTime) - session.TotalPaused
 			endTime = session.PausedAt
 		}
 
-		// Log the completed session before removing the session file
-		logEntry := core.LogEntry{
-			Tag:         session.Tag,
-			StartTime:   session.StartTime,
-			EndTime:     endTime,
-			Duration:    totalDuration,
-			TotalPaused: session.TotalPaused,
+		if atStr, _ := cmd.Flags().GetString("at"); atStr != "" {
+			if session.Pomodoro != nil {
+				fmt.Fprintf(os.Stderr, "Error: --at is not supported for pomodoro sessions\n")
+				os.Exit(1)
+			}
+			at, err := core.ParsePastTime(atStr, time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid value for --at: %v\n", err)
+				os.Exit(1)
+			}
+			if !at.After(session.StartTime) {
+				fmt.Fprintf(os.Stderr, "Error: --at must be after the session started (%s)\n", session.StartTime.Format("15:04"))
+				os.Exit(1)
+			}
+			// A paused session already stopped counting at PausedAt
+			if at.Before(endTime) {
+				endTime = at
+				session.TotalPaused = core.PausedBefore(session, at)
+			}
 		}
 
-		if err := core.LogSession(logEntry); err != nil {
+		// Log the completed session before removing the session file
+		totalDuration, err := core.LogCompletedSession(&session, endTime)
+		if err != nil {
 			// Don't fail the session end if logging fails, just warn
 			fmt.Fprintf(os.Stderr, "Warning: failed to log session: %v\n", err)
 		}
 
-		// Remove session file
-		sessionPath, err := core.GetSessionPath()
+		// Remove session file, going back to an interrupted session if there is one
+		parent, err := core.CloseSession(session, time.Now())
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error determining session path: %v\n", err)
-			os.Exit(1)
-		}
-		if err := os.Remove(sessionPath); err != nil {
 			// This is not a critical error, so we'll just warn the user.
 			fmt.Fprintf(os.Stderr, "Warning: could not remove session file: %v\n", err)
 		}
 
 		fmt.Printf("✨ Session complete: %s\n", session.Tag)
 		fmt.Printf("Total focus time: %s\n", core.FormatDuration(totalDuration))
+		printCommits(session, endTime)
 		fmt.Printf("\n%sCarry this focus forward.%s\n", core.Dim, core.Reset)
-		core.RunHook("on_end", session.Tag)
+		core.RunSessionHook(session, "on_end", session.Tag)
+		returnTo(parent, session.Tag)
 	},
 }
 
+// maxCommitsShown limits the commit summary printed when a session ends
+const maxCommitsShown = 5
+
+// printCommits summarises the commits made in the session's repository while it ran
+func printCommits(session core.Session, endTime time.Time) {
+	commits, err := core.GitCommits(session.Git, session.StartTime, endTime)
+	if err != nil || len(commits) == 0 {
+		return
+	}
+
+	noun := "commits"
+	if len(commits) == 1 {
+		noun = "commit"
+	}
+	where := session.Git.Repo()
+	if session.Git.Branch != "" {
+		where += "@" + session.Git.Branch
+	}
+	fmt.Printf("📝 %d %s in %s:\n", len(commits), noun, where)
+	for i, commit := range commits {
+		if i == maxCommitsShown {
+			fmt.Printf("%s   ...and %d more%s\n", core.Dim, len(commits)-maxCommitsShown, core.Reset)
+			break
+		}
+		fmt.Printf("   %s%s%s %s\n", core.Gray, commit.ShortHash(), core.Reset, commit.Subject)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(endCmd)
+	endCmd.Flags().String("at", "", "End the session at an earlier time (HH:MM, YYYY-MM-DD HH:MM or e.g. '15m' ago)")
 }
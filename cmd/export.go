@@ -8,7 +8,8 @@ import (
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export session data to CSV or JSON",
-	Long:  `Exports your session history to a structured format like CSV or JSON for analysis or invoicing.`,
+	Long: `Exports your session history to a structured format like CSV or JSON for analysis or invoicing.
+Use --since, --until or --range to export a period, for example --range "last month".`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Like log, this delegates to the old handler.
 		// A full refactor would move the flag parsing from core.HandleExport here.
@@ -24,4 +25,8 @@ func init() {
 	exportCmd.Flags().Bool("week", false, "Export sessions from this week")
 	exportCmd.Flags().Bool("month", false, "Export sessions from this month")
 	exportCmd.Flags().Bool("all", false, "Export all session history")
+	addDateRangeFlags(exportCmd)
+	addStatusFlags(exportCmd)
+	addProjectFlags(exportCmd)
+	exportCmd.Flags().Bool("rollup", false, "Export time per project at every level of the hierarchy instead of sessions")
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+// addStatusFlags registers the flags that select log entries by status
+func addStatusFlags(cmd *cobra.Command) {
+	statuses := strings.Join(core.Statuses, ", ")
+	cmd.Flags().String("status", "", "Only include sessions with these comma-separated statuses ("+statuses+")")
+	cmd.Flags().String("exclude-status", "", "Leave out sessions with these comma-separated statuses")
+}
+
+// addProjectFlags registers the flags that select log entries by project and label
+func addProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().String("project", "", "Only include sessions in this project or its subprojects (e.g., 'acme/web')")
+	cmd.Flags().StringSlice("label", nil, "Only include sessions with this label (repeatable; all must match)")
+}
+
+// addSessionProjectFlags registers the flags that assign a new session to a project and labels
+func addSessionProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().String("project", "", "File the session under a project (e.g., 'acme/web'); overrides a project in the tag")
+	cmd.Flags().StringSlice("label", nil, "Add a label to the session (repeatable)")
+}
+
+// addDateRangeFlags registers the flags that select log entries by date
+func addDateRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "Only include sessions from this date on (e.g., '2025-07-01', 'last monday', '2w')")
+	cmd.Flags().String("until", "", "Only include sessions up to and including this date (e.g., 'yesterday', '2025-09')")
+	cmd.Flags().String("range", "", "Only include sessions in this period (e.g., 'Q3', 'last month', '2025-07-01..2025-07-15')")
+}
+
+// dateRange reads the date range flags, exiting on an invalid date
+func dateRange(cmd *cobra.Command) core.DateRange {
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	rangeExpr, _ := cmd.Flags().GetString("range")
+	dates, err := core.ParseDateRange(since, until, rangeExpr, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return dates
+}
+
+// resolveTag splits the --tag flag into its description, project and labels,
+// merging in the project and label flags
+func resolveTag(cmd *cobra.Command) (string, string, []string) {
+	tag, _ := cmd.Flags().GetString("tag")
+	project, _ := cmd.Flags().GetString("project")
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	return core.ResolveTag(tag, project, labels)
+}
+
+// projectFilter reads the project and label flags
+func projectFilter(cmd *cobra.Command) core.ProjectFilter {
+	project, _ := cmd.Flags().GetString("project")
+	labels, _ := cmd.Flags().GetStringSlice("label")
+	return core.ProjectFilter{Project: core.NormalizeProject(project), Labels: labels}
+}
+
+// repoFilter reads the --repo and --branch flags. A repository given as a path,
+// such as ".", is resolved to the root of the repository containing it.
+func repoFilter(cmd *cobra.Command) core.RepoFilter {
+	repo, _ := cmd.Flags().GetString("repo")
+	branch, _ := cmd.Flags().GetString("branch")
+	if repo == "." || strings.ContainsRune(repo, filepath.Separator) {
+		if ctx := core.DetectGitContext(repo); ctx.Root != "" {
+			repo = ctx.Root
+		} else {
+			repo = ctx.Dir
+		}
+	}
+	return core.RepoFilter{Repo: repo, Branch: branch}
+}
+
+// statusFilter reads the status flags, exiting on an unknown status
+func statusFilter(cmd *cobra.Command) core.StatusFilter {
+	include, _ := cmd.Flags().GetString("status")
+	exclude, _ := cmd.Flags().GetString("exclude-status")
+	filter, err := core.ParseStatusFilter(include, exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return filter
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var heartbeatCmd = &cobra.Command{
+	Use:   "heartbeat",
+	Short: "Record activity in the active session",
+	Long: `Records that you are still working on the active session.
+
+Call it from editor, shell prompt or other hooks. When 'idle_timeout' is set in
+config.yml, a session that goes longer than that without a heartbeat is paused
+as of its last heartbeat, so idle time isn't logged as work. The next
+heartbeat resumes it. Prints nothing and succeeds when there is no session.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
+		if !core.SessionExists() {
+			return
+		}
+
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		if core.RecordHeartbeat(&session, config.ParsedIdleTimeout(), time.Now()) {
+			core.RunSessionHook(session, "on_resume", session.Tag)
+		}
+		if err := core.SaveSession(session); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// applyIdleTimeout counts the idle gap at the end of a session that has gone
+// without a heartbeat for too long as paused time. The caller saves the session.
+func applyIdleTimeout(session *core.Session, config core.Config) bool {
+	if !core.ApplyIdleTimeout(session, config.ParsedIdleTimeout(), time.Now()) {
+		return false
+	}
+	fmt.Printf("💤 No activity since %s; the time since then counts as paused.\n", session.PausedAt.Format("15:04"))
+	core.RunSessionHook(*session, "on_pause", session.Tag, core.IdleReason)
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(heartbeatCmd)
+}
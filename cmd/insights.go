@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/e6a5/flow/core"
@@ -13,31 +14,39 @@ import (
 var insightsCmd = &cobra.Command{
 	Use:   "insights",
 	Short: "Show insights about your work patterns",
-	Long:  `Analyzes your session history to provide insights, such as your most productive days and average session duration.`,
+	Long: `Analyzes your session history to provide insights, such as your most productive days and average session duration.
+Use --since, --until or --range to analyze a period, for example --range Q3.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		reader, err := core.NewLogReader()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating log reader: %v\n", err)
 			return
 		}
+		reader.SetStatusFilter(statusFilter(cmd))
+		reader.SetProjectFilter(projectFilter(cmd))
+		dates := dateRange(cmd)
 
-		// Read all entries for analysis
-		entries, err := reader.ReadAllEntries()
+		// Summarise the period for analysis
+		summary, err := reader.Summarize(core.LogQuery{From: dates.From, To: dates.To})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading log entries: %v\n", err)
 			return
 		}
 
-		if len(entries) < 10 { // Require a minimum amount of data for meaningful insights
-			fmt.Printf("You have logged %d sessions. At least 10 are needed for meaningful insights. Keep up the great work!\n", len(entries))
+		if summary.Total.Count < 10 { // Require a minimum amount of data for meaningful insights
+			fmt.Printf("You have logged %d sessions. At least 10 are needed for meaningful insights. Keep up the great work!\n", summary.Total.Count)
 			return
 		}
 
 		// Calculate insights
-		report := calculateInsights(entries)
+		report := calculateInsights(summary)
 
 		// Display insights
-		fmt.Printf("📊 Your Focus Insights (based on %d sessions)\n", report.TotalSessions)
+		if dates.IsZero() {
+			fmt.Printf("📊 Your Focus Insights (based on %d sessions)\n", report.TotalSessions)
+		} else {
+			fmt.Printf("📊 Your Focus Insights (based on %d sessions, %s)\n", report.TotalSessions, dates)
+		}
 		fmt.Println("----------------------------------------------------")
 		fmt.Printf("Total Time Focused:     %s\n", core.FormatDuration(report.TotalTime))
 		fmt.Printf("Average Session Length: %s\n\n", core.FormatDuration(report.AvgSessionLength))
@@ -51,6 +60,35 @@ var insightsCmd = &cobra.Command{
 				fmt.Printf("  - %-20s %-10s (%d%%)\n", activity.Tag, core.FormatDuration(activity.Duration), activity.Percent)
 			}
 		}
+
+		if len(report.Projects) > 0 {
+			fmt.Println()
+			fmt.Println("Time by Project:")
+			for _, project := range report.Projects {
+				percent := 0
+				if report.TotalTime > 0 {
+					percent = int((float64(project.Duration) / float64(report.TotalTime)) * 100)
+				}
+				name := strings.Repeat("  ", project.Depth) + project.Name()
+				fmt.Printf("  - %-20s %-10s (%d%%)\n", name, core.FormatDuration(project.Duration), percent)
+			}
+		}
+
+		if interruptions := report.Interruptions; interruptions.Count > 0 {
+			fmt.Println()
+			fmt.Printf("Interruptions:          %d (%.1f per session)\n",
+				interruptions.Count, float64(interruptions.Count)/float64(report.TotalSessions))
+			fmt.Printf("Longest Interruption:   %s\n", core.FormatDuration(interruptions.Longest.Duration()))
+			if len(interruptions.TopReasons) > 0 {
+				fmt.Println("Top Interruption Reasons:")
+				for i, reason := range interruptions.TopReasons {
+					if i >= 3 {
+						break
+					}
+					fmt.Printf("  - %-20s %d times (%s)\n", reason.Reason, reason.Count, core.FormatDuration(reason.Duration))
+				}
+			}
+		}
 		fmt.Println("----------------------------------------------------")
 	},
 }
@@ -63,6 +101,8 @@ type InsightReport struct {
 	BusiestDayAvg    time.Duration
 	OtherDaysAvg     time.Duration
 	TopActivities    []ActivityStat
+	Interruptions    core.InterruptionStats
+	Projects         []core.ProjectStat
 }
 
 type ActivityStat struct {
@@ -71,24 +111,28 @@ type ActivityStat struct {
 	Percent  int
 }
 
-func calculateInsights(entries []core.LogEntry) InsightReport {
-	report := InsightReport{TotalSessions: len(entries)}
-	if len(entries) == 0 {
+func calculateInsights(summary *core.Summary) InsightReport {
+	report := InsightReport{TotalSessions: summary.Total.Count, TotalTime: summary.Total.Duration}
+	if report.TotalSessions == 0 {
 		return report
 	}
 
 	dailyTotals := make(map[time.Weekday]time.Duration)
 	dailyCounts := make(map[time.Weekday]int)
+	for key, totals := range summary.Days {
+		day, err := time.Parse("2006-01-02", key)
+		if err != nil {
+			continue
+		}
+		dailyTotals[day.Weekday()] += totals.Duration
+		dailyCounts[day.Weekday()] += totals.Count
+	}
 	tagTotals := make(map[string]time.Duration)
-
-	for _, entry := range entries {
-		report.TotalTime += entry.Duration
-		dailyTotals[entry.EndTime.Weekday()] += entry.Duration
-		dailyCounts[entry.EndTime.Weekday()]++
-		tagTotals[entry.Tag] += entry.Duration
+	for tag, totals := range summary.Tags {
+		tagTotals[tag] = totals.Duration
 	}
 
-	report.AvgSessionLength = report.TotalTime / time.Duration(len(entries))
+	report.AvgSessionLength = report.TotalTime / time.Duration(report.TotalSessions)
 
 	var maxDuration time.Duration
 	for day, duration := range dailyTotals {
@@ -105,7 +149,7 @@ func calculateInsights(entries []core.LogEntry) InsightReport {
 	}
 
 	otherDaysTotalTime := report.TotalTime - busiestDayTotalTime
-	otherDaysSessionCount := len(entries) - busiestDaySessionCount
+	otherDaysSessionCount := report.TotalSessions - busiestDaySessionCount
 	if otherDaysSessionCount > 0 {
 		report.OtherDaysAvg = otherDaysTotalTime / time.Duration(otherDaysSessionCount)
 	}
@@ -140,9 +184,15 @@ func calculateInsights(entries []core.LogEntry) InsightReport {
 		})
 	}
 
+	report.Interruptions = summary.Interruptions()
+	report.Projects = summary.ProjectRollup()
+
 	return report
 }
 
 func init() {
 	rootCmd.AddCommand(insightsCmd)
+	addStatusFlags(insightsCmd)
+	addProjectFlags(insightsCmd)
+	addDateRangeFlags(insightsCmd)
 }
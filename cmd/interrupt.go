@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var interruptCmd = &cobra.Command{
+	Use:   "interrupt",
+	Short: "Put the current session on hold for something urgent",
+	Long: `Suspends the current session and starts a new one for an interruption.
+
+The current session is paused for as long as the interruption lasts. Use
+'flow return' (or 'flow end') when you're done to log the interruption and pick
+the original session back up. Interruptions can themselves be interrupted.
+The 'on_interrupt' hook receives the new session's tag and the suspended one's.
+
+Example:
+  flow interrupt --tag "Prod incident"
+  flow return`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
+		if !core.SessionExists() {
+			fmt.Printf("🌊 No active session to interrupt.\n")
+			fmt.Printf("Use 'flow start' to begin deep work.\n")
+			return
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		tag, project, labels := resolveTag(cmd)
+		targetStr, _ := cmd.Flags().GetString("target")
+
+		var targetDuration time.Duration
+		if targetStr != "" {
+			targetDuration, err = time.ParseDuration(targetStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid duration format for --target: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
+		next := core.InterruptSession(session, tag, time.Now())
+		next.TargetDuration = targetDuration
+		next.Project = project
+		next.Labels = labels
+		next.Git = core.CurrentGitContext()
+		if err := core.SaveSession(next); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting interruption: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("⏸️  On hold: %s\n", session.Tag)
+		fmt.Printf("🚨 Interruption: %s\n", next.Tag)
+		fmt.Printf("Use 'flow return' to get back to %s.\n", session.Tag)
+		core.RunSessionHook(next, "on_interrupt", next.Tag, session.Tag)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(interruptCmd)
+	interruptCmd.Flags().StringP("tag", "t", "Interruption", "What the interruption is about")
+	interruptCmd.Flags().String("target", "", "Set a target duration for the interruption (e.g., '30m')")
+	addSessionProjectFlags(interruptCmd)
+}
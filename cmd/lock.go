@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/e6a5/flow/core"
+)
+
+// lockSession takes the session lock for the rest of the command, so concurrent
+// flow commands can't interleave their reads and writes of the session file.
+func lockSession() func() {
+	unlock, err := core.LockSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return unlock
+}
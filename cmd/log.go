@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/e6a5/flow/core"
 	"github.com/spf13/cobra"
 )
@@ -9,21 +13,61 @@ var logCmd = &cobra.Command{
 	Use:   "log [YYYY-MM]",
 	Short: "View completed session history",
 	Long: `Displays a log of completed deep work sessions.
-You can filter the log by time periods (today, week, month) or view statistics.`,
+You can filter the log by time periods (today, week, month) or view statistics.
+Use --status and --exclude-status to select sessions by status, for example
+--exclude-status abandoned, or --status cancelled to review cancelled sessions.
+Use --project and --label to narrow the log to a project subtree or labels;
+--stats then rolls time up per project at every level of the hierarchy.
+Use --repo and --branch to see the sessions started in a git repository or on a branch.
+Use --since, --until or --range for any other period, for example
+--since "last monday", --range Q3 or --range 2025-07-01..2025-07-15.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		showStats, _ := cmd.Flags().GetBool("stats")
-		filterToday, _ := cmd.Flags().GetBool("today")
-		filterWeek, _ := cmd.Flags().GetBool("week")
-		filterMonth, _ := cmd.Flags().GetBool("month")
 		showAll, _ := cmd.Flags().GetBool("all")
 
-		monthStr := ""
-		if len(args) > 0 {
-			monthStr = args[0]
+		core.HandleLog(showStats, showAll, logPeriod(cmd, args), statusFilter(cmd), projectFilter(cmd), repoFilter(cmd))
+	},
+}
+
+// logPeriod resolves the period to show from the YYYY-MM argument or the
+// --today, --week and --month flags. --since, --until and --range take
+// precedence over them.
+func logPeriod(cmd *cobra.Command, args []string) core.DateRange {
+	if dates := dateRange(cmd); !dates.IsZero() {
+		return dates
+	}
+
+	filterToday, _ := cmd.Flags().GetBool("today")
+	filterWeek, _ := cmd.Flags().GetBool("week")
+	filterMonth, _ := cmd.Flags().GetBool("month")
+	period := ""
+	switch {
+	case len(args) > 0:
+		month, err := time.ParseInLocation("2006-01", args[0], time.Local)
+		if err != nil {
+			// A day stands for its month
+			if month, err = time.ParseInLocation("2006-01-02", args[0], time.Local); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid month format '%s'. Please use YYYY-MM.\n", args[0])
+				os.Exit(1)
+			}
 		}
+		period = month.Format("2006-01")
+	case filterToday:
+		period = "today"
+	case filterWeek:
+		period = "week"
+	case filterMonth:
+		period = "month"
+	default:
+		return core.DateRange{}
+	}
 
-		core.HandleLog(showStats, filterToday, filterWeek, filterMonth, showAll, monthStr)
-	},
+	dates, err := core.ParseDateRange("", "", period, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return dates
 }
 
 func init() {
@@ -33,4 +77,9 @@ func init() {
 	logCmd.Flags().Bool("month", false, "Show sessions from this month")
 	logCmd.Flags().Bool("stats", false, "Show summary statistics")
 	logCmd.Flags().Bool("all", false, "Show all session history")
+	addDateRangeFlags(logCmd)
+	addStatusFlags(logCmd)
+	addProjectFlags(logCmd)
+	logCmd.Flags().String("repo", "", "Only show sessions started in this git repository (name or path, e.g. '.')")
+	logCmd.Flags().String("branch", "", "Only show sessions started on this git branch (glob patterns like 'feature/*' work)")
 }
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade your data to the latest schema or move it to another storage backend",
+	Long: `Without --to, rewrites every logged session in the latest schema version.
+Flow reads entries written by older versions transparently; migrating makes the
+upgrade permanent, for example turning the old [ABANDONED] tag suffix into a
+status. The data directory is backed up under backups/ first, and --dry-run
+reports what would change without writing anything.
+
+With --to, copies the session log and the active session from the storage
+backend in use to another one. Flow stores data as monthly JSONL files by
+default; set 'storage: sqlite' in config.yml to keep it in a SQLite database
+instead. The copy leaves the current data in place and skips sessions the
+destination already has, so it is safe to run again. Switch the 'storage'
+setting once the copy is done.
+
+Examples:
+  flow migrate --dry-run
+  flow migrate
+  flow migrate --to sqlite`,
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if to == "" {
+			migrateSchema(dryRun)
+			return
+		}
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "Error: --dry-run can't be combined with --to\n")
+			os.Exit(1)
+		}
+		if !slices.Contains(core.StorageBackends, to) {
+			fmt.Fprintf(os.Stderr, "Error: --to must be one of: %s\n", strings.Join(core.StorageBackends, ", "))
+			os.Exit(1)
+		}
+
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		from := config.Storage
+		if from == "" {
+			from = core.StorageJSONL
+		}
+		if from == to {
+			fmt.Printf("🌊 Already using %s storage.\n", to)
+			return
+		}
+
+		defer lockSession()()
+
+		source, err := core.OpenStoreBackend(from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening %s storage: %v\n", from, err)
+			os.Exit(1)
+		}
+		defer func() {
+			_ = source.Close() // Only read from
+		}()
+		target, err := core.OpenStoreBackend(to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening %s storage: %v\n", to, err)
+			os.Exit(1)
+		}
+
+		result, err := core.CopyStore(source, target)
+		if closeErr := target.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error copying data to %s storage: %v\n", to, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("📦 Copied %d sessions from %s to %s storage", result.Copied, from, to)
+		if result.Skipped > 0 {
+			fmt.Printf(" (%d already there)", result.Skipped)
+		}
+		fmt.Println(".")
+		if result.Session {
+			fmt.Printf("The active session was copied too.\n")
+		}
+
+		configPath, _ := core.GetConfigPath()
+		fmt.Printf("\nSet 'storage: %s' in %s to start using it.\n", to, configPath)
+		fmt.Printf("%sThe %s data has been left in place.%s\n", core.Dim, from, core.Reset)
+	},
+}
+
+// migrateSchema upgrades the stored entries to the current schema version
+func migrateSchema(dryRun bool) {
+	defer lockSession()()
+
+	report, err := core.MigrateSchema(dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error migrating data: %v\n", err)
+		if report.Backup != "" {
+			fmt.Fprintf(os.Stderr, "Your data was backed up to %s\n", report.Backup)
+		}
+		os.Exit(1)
+	}
+
+	if len(report.Files) == 0 {
+		fmt.Println("🌊 No sessions logged yet, nothing to migrate.")
+		return
+	}
+
+	if dryRun {
+		fmt.Printf("%s🔎 Dry run: nothing has been changed.%s\n\n", core.Dim, core.Reset)
+	}
+	for _, file := range report.Files {
+		fmt.Printf("  %-24s %4d entries  %s", file.Name, file.Entries, formatVersions(file.Versions))
+		if file.Upgraded > 0 {
+			fmt.Printf("  %d to upgrade", file.Upgraded)
+		}
+		if file.Malformed > 0 {
+			fmt.Printf("  %s%d unreadable, left as is%s", core.Dim, file.Malformed, core.Reset)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+
+	upgraded := report.Upgraded()
+	switch {
+	case upgraded == 0:
+		fmt.Printf("✅ Everything is already at schema version %d.\n", core.CurrentSchemaVersion)
+	case dryRun:
+		fmt.Printf("%d entries would be upgraded to schema version %d. Run 'flow migrate' to apply.\n", upgraded, core.CurrentSchemaVersion)
+	default:
+		fmt.Printf("✅ Upgraded %d entries to schema version %d.\n", upgraded, core.CurrentSchemaVersion)
+		fmt.Printf("%sA backup of your previous data is in %s%s\n", core.Dim, report.Backup, core.Reset)
+	}
+}
+
+// formatVersions lists how many entries are stored in each schema version
+func formatVersions(versions map[int]int) string {
+	keys := make([]int, 0, len(versions))
+	for version := range versions {
+		keys = append(keys, version)
+	}
+	slices.Sort(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, version := range keys {
+		parts = append(parts, fmt.Sprintf("v%d: %d", version, versions[version]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().String("to", "", "The storage backend to copy your data to ("+strings.Join(core.StorageBackends, " or ")+")")
+	migrateCmd.Flags().Bool("dry-run", false, "Report what a schema migration would change without writing anything")
+}
@@ -12,8 +12,16 @@ import (
 var pauseCmd = &cobra.Command{
 	Use:   "pause",
 	Short: "Pause the active session",
-	Long:  `Pauses the currently active deep work session, freezing the timer.`,
+	Long: `Pauses the currently active deep work session, freezing the timer.
+
+Every pause is recorded with its start and end time, so the log keeps track
+of when and why a session was interrupted.
+
+Example:
+  flow pause --reason "slack ping"`,
 	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
 		if !core.SessionExists() {
 			fmt.Println("No active session to pause. Use 'flow start' to begin.")
 			return
@@ -25,13 +33,24 @@ var pauseCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
 		if session.IsPaused {
 			fmt.Printf("Session '%s' is already paused.\n", session.Tag)
 			return
 		}
 
+		reason, _ := cmd.Flags().GetString("reason")
+
 		session.IsPaused = true
 		session.PausedAt = time.Now()
+		session.Pauses = append(session.Pauses, core.Pause{Start: session.PausedAt, Reason: reason})
 
 		if err := core.SaveSession(session); err != nil {
 			fmt.Fprintf(os.Stderr, "Error pausing session: %v\n", err)
@@ -39,10 +58,14 @@ var pauseCmd = &cobra.Command{
 		}
 
 		fmt.Printf("⏸️  Paused session: %s\n", session.Tag)
-		core.RunHook("on_pause", session.Tag)
+		if reason != "" {
+			fmt.Printf("Reason: %s\n", reason)
+		}
+		core.RunSessionHook(session, "on_pause", session.Tag, reason)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(pauseCmd)
+	pauseCmd.Flags().StringP("reason", "r", "", "Why the session is being interrupted")
 }
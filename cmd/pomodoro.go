@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/e6a5/flow/core"
+)
+
+// pomodoroPollInterval bounds how long the foreground pomodoro timer sleeps
+// before re-reading the session, so pauses and ends from other terminals are noticed.
+const pomodoroPollInterval = 30 * time.Second
+
+// advancePomodoro moves a pomodoro session forward to its current phase, firing
+// phase hooks along the way. It returns true once the cycle is complete and the
+// session has been removed.
+func advancePomodoro(session *core.Session) (bool, error) {
+	if session.Pomodoro == nil {
+		return false, nil
+	}
+
+	changes, complete, err := core.AdvancePomodoro(session, time.Now())
+	for _, change := range changes {
+		p := session.Pomodoro
+		if change.Phase == core.PhaseBreak {
+			fmt.Printf("☕ Break time (%s). Step away from the screen.\n", core.FormatDuration(p.Break))
+		} else {
+			fmt.Printf("🌊 Round %d/%d: back to %s (%s)\n", change.Round, p.Rounds, session.Tag, core.FormatDuration(p.Work))
+		}
+		core.RunSessionHook(*session, "on_phase_change", session.Tag, change.Phase, strconv.Itoa(change.Round))
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if complete {
+		parent, err := core.CloseSession(*session, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remove session file: %v\n", err)
+		}
+		fmt.Printf("✨ Pomodoro cycle complete: %s\n", session.Tag)
+		fmt.Printf("%d rounds, total focus time: %s\n", session.Pomodoro.Rounds, core.FormatDuration(session.Pomodoro.WorkDone))
+		core.RunSessionHook(*session, "on_cycle_complete", session.Tag, session.Pomodoro.CycleID)
+		returnTo(parent, session.Tag)
+		return true, nil
+	}
+
+	if len(changes) > 0 {
+		if err := core.SaveSession(*session); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// followPomodoro keeps running in the foreground, driving the phases of the
+// pomodoro cycle until it completes or the session is ended elsewhere.
+func followPomodoro(cycleID string) {
+	for {
+		unlock := lockSession()
+		if !core.SessionExists() {
+			unlock()
+			return
+		}
+		session, err := core.LoadSession()
+		if err != nil || session.Pomodoro == nil || session.Pomodoro.CycleID != cycleID {
+			unlock()
+			return
+		}
+
+		done, err := advancePomodoro(&session)
+		unlock()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error advancing pomodoro: %v\n", err)
+			os.Exit(1)
+		}
+		if done {
+			return
+		}
+
+		wait := core.PhaseRemaining(session, time.Now())
+		if session.IsPaused || wait > pomodoroPollInterval {
+			wait = pomodoroPollInterval
+		}
+		time.Sleep(wait + 10*time.Millisecond)
+	}
+}
@@ -12,7 +12,8 @@ import (
 var recentCmd = &cobra.Command{
 	Use:   "recent",
 	Short: "Show today's completed sessions",
-	Long:  `Displays a summary of all deep work sessions completed today.`,
+	Long: `Displays a summary of all deep work sessions completed today.
+Use --since, --until or --range to summarize another period, for example --range yesterday.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		reader, err := core.NewLogReader()
 		if err != nil {
@@ -20,28 +21,47 @@ var recentCmd = &cobra.Command{
 			return
 		}
 
-		// Read entries for today, with a reasonable limit for performance
-		entries, err := reader.ReadRecentEntries(100, true, false)
+		dates := dateRange(cmd)
+		q := core.LogQuery{From: dates.From, To: dates.To}
+		if dates.IsZero() {
+			// Read entries for today, with a reasonable limit for performance
+			today, _ := core.ParseDateRange("", "", "today", time.Now())
+			q = core.LogQuery{From: today.From, To: today.To, Limit: 100}
+		}
+		entries, err := reader.Query(q)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading log entries: %v\n", err)
 			return
 		}
 
 		if len(entries) == 0 {
-			fmt.Println("No sessions completed today. Keep up the focus!")
+			if dates.IsZero() {
+				fmt.Println("No sessions completed today. Keep up the focus!")
+			} else {
+				fmt.Printf("No sessions completed in the selected period (%s).\n", dates)
+			}
 			return
 		}
 
-		fmt.Printf("✨ Today's Completed Sessions ✨\n\n")
+		if dates.IsZero() {
+			fmt.Printf("✨ Today's Completed Sessions ✨\n\n")
+		} else {
+			fmt.Printf("✨ Completed Sessions (%s) ✨\n\n", dates)
+		}
 		var totalTime time.Duration
 		for _, entry := range entries {
 			fmt.Printf("  - %s (%s)\n", entry.Tag, core.FormatDuration(entry.Duration))
 			totalTime += entry.Duration
 		}
-		fmt.Printf("\nTotal focus time today: %s\n", core.FormatDuration(totalTime))
+		if dates.IsZero() {
+			fmt.Printf("\nTotal focus time today: %s\n", core.FormatDuration(totalTime))
+		} else {
+			fmt.Printf("\nTotal focus time: %s\n", core.FormatDuration(totalTime))
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(recentCmd)
+	addDateRangeFlags(recentCmd)
 }
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Choose how much of a forgotten session to log",
+	Long: `Ends the active session, letting you choose how much of it to keep.
+
+Use it when you forgot to end a session and it has been running far longer than
+you actually worked. You can keep all of it, keep it up to its last recorded
+activity (a heartbeat, pause or resume), keep the first stale_session_threshold
+worth of it, keep it up to a time you enter, or discard it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
+		if !core.SessionExists() {
+			fmt.Printf("🌊 No active session to recover.\n")
+			return
+		}
+
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		if session.Pomodoro != nil {
+			fmt.Fprintf(os.Stderr, "Error: pomodoro sessions log each phase as it ends; use 'flow end' instead\n")
+			os.Exit(1)
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+		if !recoverSession(scanner, session, config, false) {
+			fmt.Println("Left the session as it is.")
+		}
+	},
+}
+
+// handleStaleSession applies the configured stale_policy to a stale session.
+// It returns false if the session was left in place.
+func handleStaleSession(session core.Session, config core.Config) bool {
+	threshold := config.ParsedStaleSessionThreshold()
+	fmt.Printf("⚠️  Found a stale session: %s (running for over %s)\n", session.Tag, core.FormatDuration(threshold))
+
+	if config.StalePolicy == core.StalePolicyPrompt {
+		return recoverSession(bufio.NewScanner(os.Stdin), session, config, true)
+	}
+
+	end, keep := core.StaleEndTime(session, config.StalePolicy, threshold, time.Now())
+	if !keep {
+		if err := core.DiscardSession(session); err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning up stale session: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("   Discarded it without logging.\n")
+		return true
+	}
+	if err := core.EndSessionAt(session, end, true); err != nil {
+		fmt.Fprintf(os.Stderr, "Error cleaning up stale session: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("   Logged it as abandoned, ending at %s.\n", end.Format("Jan 2 15:04"))
+	return true
+}
+
+// recoverSession asks how much of a session to log and ends it accordingly.
+// With no answer, a stale session falls back to the keep policy. It returns
+// false if the user chose to leave the session alone.
+func recoverSession(scanner *bufio.Scanner, session core.Session, config core.Config, stale bool) bool {
+	now := time.Now()
+	threshold := config.ParsedStaleSessionThreshold()
+	fullEnd, _ := core.StaleEndTime(session, core.StalePolicyKeep, threshold, now)
+	lastEnd, _ := core.StaleEndTime(session, core.StalePolicyLastActivity, threshold, now)
+	trimEnd, _ := core.StaleEndTime(session, core.StalePolicyTrim, threshold, now)
+
+	worked := func(end time.Time) string {
+		if session.IsPaused && end.After(session.PausedAt) {
+			end = session.PausedAt
+		}
+		return core.FormatDuration(end.Sub(session.StartTime) - core.PausedBefore(session, end))
+	}
+
+	fmt.Printf("\n%s, started %s\n", session.Tag, session.StartTime.Format("Mon Jan 2 15:04"))
+	fmt.Printf("How much of it should be logged?\n")
+	fmt.Printf("  1) All of it, until %s (%s)\n", fullEnd.Format("Jan 2 15:04"), worked(fullEnd))
+	fmt.Printf("  2) Until the last activity at %s (%s)\n", lastEnd.Format("Jan 2 15:04"), worked(lastEnd))
+	fmt.Printf("  3) The first %s\n", worked(trimEnd))
+	fmt.Printf("  4) Until a time you enter\n")
+	fmt.Printf("  5) None of it\n")
+	fmt.Printf("  q) Leave it for now\n")
+
+	for {
+		fmt.Print("Choice: ")
+		if !scanner.Scan() {
+			fmt.Println()
+			if !stale {
+				return false
+			}
+			// Nobody to ask; keep the old behaviour
+			end, _ := core.StaleEndTime(session, core.StalePolicyKeep, threshold, now)
+			if err := core.EndSessionAt(session, end, true); err != nil {
+				fmt.Fprintf(os.Stderr, "Error cleaning up stale session: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("   Logged it as abandoned, ending at %s.\n", end.Format("Jan 2 15:04"))
+			return true
+		}
+
+		var end time.Time
+		switch choice := strings.TrimSpace(strings.ToLower(scanner.Text())); choice {
+		case "1":
+			end = fullEnd
+		case "2":
+			end = lastEnd
+		case "3":
+			end = trimEnd
+		case "4":
+			fmt.Print("End time (HH:MM or YYYY-MM-DD HH:MM): ")
+			if !scanner.Scan() {
+				return false
+			}
+			t, err := core.ParsePastTime(strings.TrimSpace(scanner.Text()), now)
+			if err != nil || !t.After(session.StartTime) {
+				fmt.Println("Please enter a time after the session started.")
+				continue
+			}
+			end = t
+		case "5":
+			if err := core.DiscardSession(session); err != nil {
+				fmt.Fprintf(os.Stderr, "Error removing session: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("🗑️  Discarded session: %s\n", session.Tag)
+			return true
+		case "q", "":
+			return false
+		default:
+			fmt.Println("Please choose 1-5 or q.")
+			continue
+		}
+
+		if err := core.EndSessionAt(session, end, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Error logging session: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✨ Logged session: %s (%s)\n", session.Tag, worked(end))
+		return true
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+}
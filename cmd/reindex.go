@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the summary index used by the dashboard and insights",
+	Long: `Flow keeps a summary of each monthly log file (time per day, tag and
+project, session counts and interruptions) in index.json in the log directory,
+so 'flow dashboard' and 'flow insights' don't have to read every session.
+The index is updated as sessions are logged, edited and deleted, and a
+month's summary is rebuilt automatically when its file changes in any other
+way. Run this to rebuild the whole index from scratch.
+
+The SQLite backend keeps no summary index.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		report, err := core.Reindex()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rebuilding the index: %v\n", err)
+			os.Exit(1)
+		}
+		if report.Path == "" {
+			fmt.Println("The storage backend in use keeps no summary index; nothing to rebuild.")
+			return
+		}
+		fmt.Printf("✅ Indexed %d sessions in %d log files.\n", report.Sessions, report.Files)
+		fmt.Printf("%s%s%s\n", core.Dim, report.Path, core.Reset)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+}
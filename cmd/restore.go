@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <backup.tar.gz>",
+	Short: "Restore a backup made by 'flow backup'",
+	Long: `Checks a backup against its manifest, shows what restoring it would change,
+and asks before changing anything.
+
+With --replace (the default), your sessions, logs, config and hooks are made
+to match the backup exactly: files the backup doesn't have are removed.
+
+With --merge, the backup's sessions that you don't have yet are added,
+matching sessions by ID so nothing is duplicated, and the lines missing from
+other log files such as break logs are appended. Your active session, config
+and hooks are kept; only those missing are taken from the backup.
+
+Either way, your current data is first backed up to backups/ in the data
+directory, so a restore can be undone by restoring that.
+
+Examples:
+  flow restore flow-backup-20250115-093000.tar.gz --dry-run
+  flow restore old-laptop.tar.gz --merge`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		merge, _ := cmd.Flags().GetBool("merge")
+		replace, _ := cmd.Flags().GetBool("replace")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		if merge && replace {
+			fmt.Fprintln(os.Stderr, "Error: --merge and --replace can't be used together")
+			os.Exit(1)
+		}
+		mode := core.RestoreReplace
+		if merge {
+			mode = core.RestoreMerge
+		}
+
+		backup, err := core.OpenBackup(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() {
+			_ = backup.Close()
+		}()
+
+		defer lockSession()()
+
+		plan, err := backup.Plan(mode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error planning restore: %v\n", err)
+			_ = backup.Close()
+			os.Exit(1)
+		}
+		manifest := backup.Manifest
+		fmt.Printf("Backup of %d files taken %s", len(manifest.Files), manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+		if manifest.Host != "" {
+			fmt.Printf(" on %s", manifest.Host)
+		}
+		fmt.Printf(" (%s storage)\n\n", manifest.Storage)
+		printRestorePlan(plan)
+
+		if !hasRestoreChanges(plan) {
+			fmt.Println("\n✅ Your data already matches the backup; nothing to restore.")
+			return
+		}
+		if dryRun {
+			fmt.Printf("\n%sDry run: nothing was changed.%s\n", core.Dim, core.Reset)
+			return
+		}
+		if !yes {
+			fmt.Printf("\nRestore this backup (%s)? (y/N) ", mode)
+			scanner := bufio.NewScanner(os.Stdin)
+			scanner.Scan()
+			if strings.ToLower(scanner.Text()) != "y" {
+				fmt.Println("Operation cancelled.")
+				return
+			}
+		}
+
+		plan, err = backup.Restore(mode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring backup: %v\n", err)
+			if plan.Safety != "" {
+				fmt.Fprintf(os.Stderr, "Your data before the restore is in %s\n", plan.Safety)
+			}
+			_ = backup.Close()
+			os.Exit(1)
+		}
+		fmt.Println("\n✅ Backup restored.")
+		fmt.Printf("%sYour previous data is in %s%s\n", core.Dim, plan.Safety, core.Reset)
+	},
+}
+
+// printRestorePlan lists the changes a restore makes
+func printRestorePlan(plan core.RestorePlan) {
+	fmt.Printf("Sessions: %d to add", plan.Added)
+	if plan.Mode == core.RestoreReplace {
+		fmt.Printf(", %d to remove", plan.Removed)
+	}
+	fmt.Printf(", %d already present\n", plan.Unchanged)
+
+	unchanged := 0
+	for _, change := range plan.Changes {
+		if change.Action == core.RestoreUnchanged {
+			unchanged++
+			continue
+		}
+		fmt.Printf("  %-9s %s\n", change.Action, change.Path)
+	}
+	if unchanged > 0 {
+		fmt.Printf("%s  %d files unchanged%s\n", core.Dim, unchanged, core.Reset)
+	}
+}
+
+// hasRestoreChanges reports whether restoring the plan would change anything
+func hasRestoreChanges(plan core.RestorePlan) bool {
+	if plan.Added > 0 || plan.Removed > 0 {
+		return true
+	}
+	for _, change := range plan.Changes {
+		if change.Action != core.RestoreUnchanged && change.Action != core.RestoreKeep {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().Bool("replace", false, "Make your data match the backup exactly (the default)")
+	restoreCmd.Flags().Bool("merge", false, "Add what the backup has that you don't, keeping everything else")
+	restoreCmd.Flags().Bool("dry-run", false, "Show what would change without restoring")
+	restoreCmd.Flags().BoolP("yes", "y", false, "Restore without asking for confirmation")
+}
@@ -14,6 +14,8 @@ var resumeCmd = &cobra.Command{
 	Short: "Resume a paused session",
 	Long:  `Resumes a previously paused deep work session, restarting the timer.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
 		if !core.SessionExists() {
 			fmt.Printf("🌊 No session to resume.\n")
 			return
@@ -25,15 +27,20 @@ var resumeCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
 		if !session.IsPaused {
 			fmt.Printf("🌊 Session already active: %s\n", session.Tag)
 			return
 		}
 
-		// Calculate total paused time
-		session.TotalPaused += time.Since(session.PausedAt)
-		session.IsPaused = false
-		session.PausedAt = time.Time{}
+		core.ResumeSession(&session, time.Now())
 
 		if err := core.SaveSession(session); err != nil {
 			fmt.Fprintf(os.Stderr, "Error resuming session: %v\n", err)
@@ -42,7 +49,7 @@ var resumeCmd = &cobra.Command{
 
 		fmt.Printf("🌊 Resumed: %s\n", session.Tag)
 		fmt.Printf("Continue your deep work.\n")
-		core.RunHook("on_resume", session.Tag)
+		core.RunSessionHook(session, "on_resume", session.Tag)
 	},
 }
 
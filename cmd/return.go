@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var returnCmd = &cobra.Command{
+	Use:   "return",
+	Short: "Finish an interruption and resume the session it interrupted",
+	Long: `Ends and logs the current interruption and resumes the session that was put
+on hold by 'flow interrupt'. The time spent on the interruption is recorded
+as paused time on the resumed session.
+
+The 'on_end' hook runs for the interruption, then 'on_return' for the resumed session.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
+		if !core.SessionExists() {
+			fmt.Printf("🌊 No active session.\n")
+			return
+		}
+
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(session.Interrupted) == 0 {
+			fmt.Printf("🌊 %s didn't interrupt another session.\n", session.Tag)
+			fmt.Printf("Use 'flow end' to finish it.\n")
+			return
+		}
+
+		applyIdleTimeout(&session, config)
+
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
+		endTime := time.Now()
+		if session.IsPaused {
+			endTime = session.PausedAt
+		}
+		totalDuration, err := core.LogCompletedSession(&session, endTime)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log session: %v\n", err)
+		}
+
+		parent, err := core.CloseSession(session, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resuming session: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✨ Interruption complete: %s (%s)\n", session.Tag, core.FormatDuration(totalDuration))
+		core.RunSessionHook(session, "on_end", session.Tag)
+		returnTo(parent, session.Tag)
+	},
+}
+
+// returnTo reports that a finished interruption handed back to the session it
+// interrupted, if any, and fires the 'on_return' hook.
+func returnTo(parent *core.Session, from string) {
+	if parent == nil {
+		return
+	}
+	fmt.Printf("↩️  Back to: %s\n", parent.Tag)
+	if parent.IsPaused {
+		fmt.Printf("It's still paused; use 'flow resume' to continue.\n")
+	}
+	if len(parent.Interrupted) > 0 {
+		fmt.Printf("Still on hold: %s\n", parent.Interrupted[len(parent.Interrupted)-1].Tag)
+	}
+	core.RunSessionHook(*parent, "on_return", parent.Tag, from)
+}
+
+func init() {
+	rootCmd.AddCommand(returnCmd)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the details of a logged session",
+	Long: `Shows everything recorded for a single logged session.
+
+The session is identified by its ID, either in full or the short form shown by 'flow log'.
+
+Example:
+  flow show 3f9k2m7q`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := core.FindLogEntry(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🌊 %s\n\n", entry.Tag)
+		fmt.Printf("ID:         %s\n", entry.ID)
+		fmt.Printf("Started:    %s\n", entry.StartTime.Format("Mon Jan 2, 2006 15:04"))
+		fmt.Printf("Ended:      %s\n", entry.EndTime.Format("Mon Jan 2, 2006 15:04"))
+		fmt.Printf("Focus time: %s\n", core.FormatDuration(entry.Duration))
+		fmt.Printf("Status:     %s\n", entry.Status)
+		if entry.Project != "" {
+			fmt.Printf("Project:    %s\n", entry.Project)
+		}
+		if len(entry.Labels) > 0 {
+			fmt.Printf("Labels:     #%s\n", strings.Join(entry.Labels, " #"))
+		}
+		if git := entry.Git; git != nil {
+			fmt.Printf("Directory:  %s\n", git.Dir)
+			if git.Root != "" {
+				fmt.Printf("Repository: %s", git.Root)
+				if git.Remote != "" {
+					fmt.Printf(" (%s)", git.Remote)
+				}
+				fmt.Println()
+			}
+			if git.Branch != "" {
+				fmt.Printf("Branch:     %s\n", git.Branch)
+			}
+		}
+		if entry.TotalPaused > 0 {
+			fmt.Printf("Paused:     %s\n", core.FormatDuration(entry.TotalPaused))
+		}
+		if entry.CycleID != "" {
+			fmt.Printf("Pomodoro:   round %d of cycle %s\n", entry.Round, entry.CycleID)
+		}
+
+		if len(entry.Commits) > 0 {
+			fmt.Printf("\nCommits:\n")
+			for _, commit := range entry.Commits {
+				fmt.Printf("  %s %s\n", commit.ShortHash(), commit.Subject)
+			}
+		}
+
+		if len(entry.Pauses) > 0 {
+			fmt.Printf("\nInterruptions:\n")
+			for _, pause := range entry.Pauses {
+				reason := pause.Reason
+				if reason == "" {
+					reason = "no reason given"
+				}
+				fmt.Printf("  %s-%s %s (%s)\n",
+					pause.Start.Format("15:04"), pause.End.Format("15:04"),
+					core.FormatDuration(pause.Duration()), reason)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
@@ -3,20 +3,57 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/e6a5/flow/core"
 	"github.com/spf13/cobra"
 )
 
+// pomodoroRoundsArg matches a round count given as a separate argument to --pomodoro
+var pomodoroRoundsArg = regexp.MustCompile(`^x\d+$`)
+
 var startCmd = &cobra.Command{
-	Use:   "start",
+	Use:   "start [preset]",
 	Short: "Begin a deep work session",
 	Long: `Starts a new deep work session.
 
 A session is a single, uninterrupted period of focus.
 You can add a descriptive tag to your session to remember what you worked on.
-If a session is already active, 'start' will show you the status instead.`,
+If a session is already active, 'start' will show you the status instead.
+
+With --pomodoro, the session alternates between work and break phases.
+Each work phase is logged as its own entry, and 'start' keeps running in the
+foreground to move between phases (use --detach to return immediately; phases
+are then advanced whenever you run 'flow status').
+
+Use --at to backdate the start if you forgot to run 'start' when you began.
+
+Without --tag, the auto_tag rules in config.yml and in .flow.yml files in the
+current directory or its parents can name the session after the directory,
+git branch or environment it is started in.
+
+Presets defined in config.yml bundle a tag, target, pomodoro plan and extra
+hooks under a name. Flags given alongside a preset override its settings.
+
+A tag starting with a project path files the session under that project, and
+words starting with # become labels: "acme/web Fix login #bug". Use --project
+and --label to set them explicitly.
+
+Example:
+  flow start --tag "acme/web Fix login #bug"
+  flow start --tag "Sprint" --pomodoro 25m/5m x4
+  flow start --tag "Planning" --at 09:30
+  flow start writing`,
+	Args: cobra.MaximumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completePresets(cmd, args, toComplete)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load configuration
 		config, err := core.LoadConfig()
@@ -25,6 +62,9 @@ If a session is already active, 'start' will show you the status instead.`,
 			os.Exit(1)
 		}
 
+		unlock := lockSession()
+		defer unlock()
+
 		// Check if session already exists
 		if core.SessionExists() {
 			session, err := core.LoadSession()
@@ -35,21 +75,11 @@ If a session is already active, 'start' will show you the status instead.`,
 
 			// Check if the session is stale (running for too long)
 			if core.IsSessionStale(session, config.ParsedStaleSessionThreshold()) {
-				duration := time.Since(session.StartTime) - session.TotalPaused
-				if session.IsPaused {
-					duration = session.PausedAt.Sub(session.StartTime) - session.TotalPaused
+				// Clean up the stale session according to stale_policy
+				if !handleStaleSession(session, config) {
+					fmt.Printf("Use 'flow end' or 'flow recover' to finish it first.\n")
+					return
 				}
-
-				// Automatically clean up the stale session
-				if err := core.CleanupStaleSession(session, true); err != nil {
-					fmt.Fprintf(os.Stderr, "Error cleaning up stale session: %v\n", err)
-					os.Exit(1)
-				}
-
-				thresholdStr := core.FormatDuration(config.ParsedStaleSessionThreshold())
-				fmt.Printf("⚠️  Found and cleaned up a stale session: %s\n", session.Tag)
-				fmt.Printf("   Duration: %s (logged as abandoned)\n", core.FormatDuration(duration))
-				fmt.Printf("   Threshold: %s\n", thresholdStr)
 				fmt.Printf("   Starting fresh session...\n\n")
 			} else {
 				// Normal existing session (not stale)
@@ -68,6 +98,60 @@ If a session is already active, 'start' will show you the status instead.`,
 
 		tag, _ := cmd.Flags().GetString("tag")
 		targetStr, _ := cmd.Flags().GetString("target")
+		pomodoroStr, _ := cmd.Flags().GetString("pomodoro")
+		presetName, _ := cmd.Flags().GetString("preset")
+		project, _ := cmd.Flags().GetString("project")
+		labels, _ := cmd.Flags().GetStringSlice("label")
+
+		for _, arg := range args {
+			switch {
+			case pomodoroStr != "" && pomodoroRoundsArg.MatchString(arg):
+				// Allow the round count as a separate argument: --pomodoro 25m/5m x4
+				pomodoroStr += " " + arg
+			case presetName == "":
+				presetName = arg
+			default:
+				fmt.Fprintf(os.Stderr, "Error: unexpected argument %q\n", arg)
+				os.Exit(1)
+			}
+		}
+
+		if presetName != "" {
+			preset, err := config.Preset(presetName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			// Flags given explicitly win over the preset
+			if !cmd.Flags().Changed("tag") {
+				tag = preset.Tag
+			}
+			if !cmd.Flags().Changed("target") {
+				targetStr = preset.Target
+			}
+			if !cmd.Flags().Changed("pomodoro") {
+				pomodoroStr = preset.Pomodoro
+			}
+			if !cmd.Flags().Changed("project") {
+				project = preset.Project
+			}
+			labels = slices.Concat(preset.Labels, labels)
+		}
+
+		git := core.CurrentGitContext()
+		if presetName == "" && !cmd.Flags().Changed("tag") {
+			if rule, ok := autoTag(config, git); ok {
+				if rule.Tag != "" {
+					tag = rule.Tag
+				}
+				if !cmd.Flags().Changed("project") {
+					project = rule.Project
+				}
+				labels = slices.Concat(rule.Labels, labels)
+			}
+		}
+		tag, project, labels = core.ResolveTag(tag, project, labels)
+
 		var targetDuration time.Duration
 		if targetStr != "" {
 			var err error
@@ -78,12 +162,53 @@ If a session is already active, 'start' will show you the status instead.`,
 			}
 		}
 
+		var plan *core.Pomodoro
+		if pomodoroStr != "" {
+			p, err := core.ParsePomodoroSpec(pomodoroStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid value for --pomodoro: %v\n", err)
+				os.Exit(1)
+			}
+			plan = &p
+		}
+
+		startTime := time.Now()
+		if atStr, _ := cmd.Flags().GetString("at"); atStr != "" {
+			at, err := core.ParsePastTime(atStr, startTime)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid value for --at: %v\n", err)
+				os.Exit(1)
+			}
+			if at.After(startTime) {
+				fmt.Fprintf(os.Stderr, "Error: --at cannot be in the future\n")
+				os.Exit(1)
+			}
+			overlaps, err := core.FindOverlaps(at, startTime)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error checking for overlapping sessions: %v\n", err)
+				os.Exit(1)
+			}
+			if len(overlaps) > 0 {
+				fmt.Fprintf(os.Stderr, "Error: starting at %s would overlap logged sessions:\n", at.Format("15:04"))
+				printOverlaps(overlaps)
+				os.Exit(1)
+			}
+			startTime = at
+		}
+
 		// Create new session
 		session := core.Session{
 			Tag:            tag,
-			StartTime:      time.Now(),
+			StartTime:      startTime,
 			IsPaused:       false,
 			TargetDuration: targetDuration,
+			Preset:         presetName,
+			Project:        project,
+			Labels:         labels,
+			Git:            git,
+		}
+		if plan != nil {
+			session.Pomodoro = core.StartPomodoro(*plan, session.StartTime)
 		}
 
 		if err := core.SaveSession(session); err != nil {
@@ -97,14 +222,51 @@ If a session is already active, 'start' will show you the status instead.`,
 		fmt.Printf("%s   Focus on what matters%s\n", core.Dim, core.Reset)
 		fmt.Printf("%s   Let distractions pass%s\n", core.Dim, core.Reset)
 		fmt.Printf("\nDeep work session initiated.\n")
+		printProject(session.Project, session.Labels)
+		if plan != nil {
+			fmt.Printf("🍅 Pomodoro: %d rounds of %s work / %s break\n",
+				plan.Rounds, core.FormatDuration(plan.Work), core.FormatDuration(plan.Break))
+		}
 		fmt.Printf("%sUse 'flow status' to check, 'flow end' to complete.%s\n\n", core.Gray, core.Reset)
 
-		core.RunHook("on_start", session.Tag)
+		core.RunSessionHook(session, "on_start", session.Tag)
+
+		detach, _ := cmd.Flags().GetBool("detach")
+		if plan != nil && !detach {
+			unlock()
+			followPomodoro(session.Pomodoro.CycleID)
+		}
 	},
 }
 
+// autoTag finds the auto-tag rule for a session started in git.Dir. Broken
+// rules are reported but don't stop the session from starting.
+func autoTag(config core.Config, git *core.GitContext) (core.TagRule, bool) {
+	if git == nil {
+		return core.TagRule{}, false
+	}
+	rules, err := config.TagRules(git.Dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load auto-tag rules: %v\n", err)
+		return core.TagRule{}, false
+	}
+	rule, ok, err := core.MatchTagRule(rules, git)
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", line)
+		}
+	}
+	return rule, ok
+}
+
 func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.Flags().StringP("tag", "t", "Deep Work", "A description of the work session")
 	startCmd.Flags().String("target", "", "Set a target duration for the session (e.g., '1h30m', '2h')")
+	startCmd.Flags().String("pomodoro", "", "Run alternating work/break phases (e.g., '25m/5m x4')")
+	startCmd.Flags().String("at", "", "Backdate the start (HH:MM, YYYY-MM-DD HH:MM or e.g. '15m' ago)")
+	addSessionProjectFlags(startCmd)
+	startCmd.Flags().String("preset", "", "Start a session from a preset defined in config.yml")
+	_ = startCmd.RegisterFlagCompletionFunc("preset", completePresets)
+	startCmd.Flags().Bool("detach", false, "With --pomodoro, return immediately instead of driving the phases in the foreground")
 }
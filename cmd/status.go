@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/e6a5/flow/core"
@@ -14,9 +15,17 @@ var statusCmd = &cobra.Command{
 	Short: "Check the current session status",
 	Long: `Shows the status of the current deep work session.
 This includes the session tag, how long it has been active, and progress if a target was set.
-The --raw flag can be used to output only the session tag for scripting purposes.`,
+The --raw flag can be used to output only the session tag for scripting purposes.
+
+In sync mode, it also warns when another device sharing the data directory
+already has a session running.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		raw, _ := cmd.Flags().GetBool("raw")
+		if !raw {
+			// Advancing a pomodoro session writes to it; --raw stays lock-free for prompts
+			defer lockSession()()
+			warnOtherDevices()
+		}
 
 		if !core.SessionExists() {
 			if raw {
@@ -46,6 +55,25 @@ The --raw flag can be used to output only the session tag for scripting purposes
 			return
 		}
 
+		if device := core.DeviceID(); session.Device != "" && session.Device != device {
+			fmt.Printf("⚠️  This session was started on %s, not on this device (%s).\n\n", session.Device, device)
+		}
+
+		if applyIdleTimeout(&session, config) {
+			if err := core.SaveSession(session); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+		if done {
+			return
+		}
+
 		// Check if session is stale and warn the user
 		if core.IsSessionStale(session, config.ParsedStaleSessionThreshold()) {
 			duration := time.Since(session.StartTime) - session.TotalPaused
@@ -91,9 +119,68 @@ The --raw flag can be used to output only the session tag for scripting purposes
 				fmt.Printf("%s\n", baseMsg)
 			}
 		}
+
+		printProject(session.Project, session.Labels)
+
+		if p := session.Pomodoro; p != nil {
+			phase := "Work"
+			if p.Phase == core.PhaseBreak {
+				phase = "Break"
+			}
+			fmt.Printf("🍅 %s %d/%d (%s remaining in phase)\n",
+				phase, p.Round, p.Rounds, core.FormatDuration(core.PhaseRemaining(session, time.Now())))
+		}
+
+		// Show the sessions on hold, innermost first
+		for i := len(session.Interrupted) - 1; i >= 0; i-- {
+			held := session.Interrupted[i]
+			indent := strings.Repeat("  ", len(session.Interrupted)-i)
+			fmt.Printf("%s↳ on hold: %s (worked %s, on hold for %s)\n",
+				indent, held.Tag,
+				core.FormatDuration(held.PausedAt.Sub(held.StartTime)-held.TotalPaused),
+				core.FormatDuration(time.Since(held.PausedAt)))
+		}
+		if len(session.Interrupted) > 0 {
+			fmt.Printf("Use 'flow return' to get back to %s.\n", session.Interrupted[len(session.Interrupted)-1].Tag)
+		}
 	},
 }
 
+// warnOtherDevices warns about the sessions running on other devices in sync mode
+func warnOtherDevices() {
+	sessions, err := core.OtherDeviceSessions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: checking other devices: %v\n", err)
+		return
+	}
+	for _, other := range sessions {
+		state := "running"
+		if other.Session.IsPaused {
+			state = "paused"
+		}
+		fmt.Printf("⚠️  %s already has a session %s: %s (started %s)\n",
+			other.Device, state, other.Session.Tag, other.Session.StartTime.Local().Format("Jan 2 15:04"))
+	}
+	if len(sessions) > 0 {
+		fmt.Println()
+	}
+}
+
+// printProject shows the project and labels a session is filed under, if any
+func printProject(project string, labels []string) {
+	if project == "" && len(labels) == 0 {
+		return
+	}
+	var parts []string
+	if project != "" {
+		parts = append(parts, "📁 "+project)
+	}
+	for _, label := range labels {
+		parts = append(parts, "#"+label)
+	}
+	fmt.Printf("%s%s%s\n", core.Gray, strings.Join(parts, " "), core.Reset)
+}
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
 	statusCmd.Flags().Bool("raw", false, "Output only the session tag for scripting")
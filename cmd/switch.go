@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/e6a5/flow/core"
+	"github.com/spf13/cobra"
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch",
+	Short: "End the current session and start the next one",
+	Long: `Ends and logs the current session and starts a new one at the same instant.
+
+This keeps your timeline contiguous when you move from one task to the next.
+The 'on_end' hook runs for the finished session, then 'on_start' for the new one.
+If the current session is paused, it ends where it was paused and the new session starts now.
+
+Example:
+  flow switch --tag "Code review"
+  flow switch --tag "Writing" --keep-target`,
+	Run: func(cmd *cobra.Command, args []string) {
+		defer lockSession()()
+
+		if !core.SessionExists() {
+			fmt.Printf("🌊 No active session to switch from.\n")
+			fmt.Printf("Use 'flow start' to begin deep work.\n")
+			return
+		}
+
+		config, err := core.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		session, err := core.LoadSession()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading session: %v\n", err)
+			os.Exit(1)
+		}
+
+		tag, project, labels := resolveTag(cmd)
+		keepTarget, _ := cmd.Flags().GetBool("keep-target")
+		targetStr, _ := cmd.Flags().GetString("target")
+
+		var targetDuration time.Duration
+		if targetStr != "" {
+			targetDuration, err = time.ParseDuration(targetStr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Invalid duration format for --target: %v\n", err)
+				os.Exit(1)
+			}
+		} else if keepTarget {
+			targetDuration = session.TargetDuration
+		}
+
+		applyIdleTimeout(&session, config)
+
+		done, err := advancePomodoro(&session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to log pomodoro phases: %v\n", err)
+		}
+
+		now := time.Now()
+		// Switching replaces only the top of the stack, even when a finished
+		// pomodoro cycle has already handed the session back to the one it held
+		interrupted := session.Interrupted
+		if !done {
+			if core.IsSessionStale(session, config.ParsedStaleSessionThreshold()) {
+				// Don't carry hours of forgotten time into the log as real work
+				if !handleStaleSession(session, config) {
+					fmt.Printf("Use 'flow end' or 'flow recover' to finish it first.\n")
+					return
+				}
+				// The sessions it held were closed with it
+				interrupted = nil
+			} else {
+				endTime := now
+				if session.IsPaused {
+					endTime = session.PausedAt
+				}
+				totalDuration, err := core.LogCompletedSession(&session, endTime)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to log session: %v\n", err)
+				}
+				fmt.Printf("✨ Session complete: %s (%s)\n", session.Tag, core.FormatDuration(totalDuration))
+				printCommits(session, endTime)
+				core.RunSessionHook(session, "on_end", session.Tag)
+			}
+		}
+
+		// Overwrite the session file in one write; there is no moment without a session
+		next := core.Session{
+			Tag:            tag,
+			StartTime:      now,
+			TargetDuration: targetDuration,
+			Interrupted:    interrupted,
+			Project:        project,
+			Labels:         labels,
+			Git:            core.CurrentGitContext(),
+		}
+		if err := core.SaveSession(next); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting session: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🌊 Switched to: %s\n", next.Tag)
+		if next.TargetDuration > 0 {
+			fmt.Printf("Target: %s\n", core.FormatDuration(next.TargetDuration))
+		}
+		printProject(next.Project, next.Labels)
+		if len(next.Interrupted) > 0 {
+			fmt.Printf("Still on hold: %s\n", next.Interrupted[len(next.Interrupted)-1].Tag)
+		}
+		core.RunSessionHook(next, "on_start", next.Tag)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+	switchCmd.Flags().StringP("tag", "t", "Deep Work", "A description of the next work session")
+	switchCmd.Flags().String("target", "", "Set a target duration for the next session (e.g., '1h30m', '2h')")
+	switchCmd.Flags().Bool("keep-target", false, "Carry the current session's target duration over to the next session")
+	addSessionProjectFlags(switchCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/e6a5/flow/core"
+)
+
+func TestSwitchAtEndOfPomodoroKeepsHeldSessions(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// A one-round pomodoro interrupting a session, past the end of its cycle
+	now := time.Now()
+	feature := core.Session{Tag: "Feature", StartTime: now.Add(-2 * time.Hour)}
+	focus := core.InterruptSession(feature, "Focus", now.Add(-40*time.Minute))
+	focus.Pomodoro = core.StartPomodoro(core.Pomodoro{Work: 25 * time.Minute, Break: 5 * time.Minute, Rounds: 1}, focus.StartTime)
+	if err := core.SaveSession(focus); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := switchCmd.Flags().Set("tag", "Next"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = switchCmd.Flags().Set("tag", "Deep Work") })
+	switchCmd.Run(switchCmd, []string{})
+
+	session, err := core.LoadSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Tag != "Next" || len(session.Interrupted) != 1 || session.Interrupted[0].Tag != "Feature" {
+		t.Errorf("Session after switch = %q holding %+v, want Next holding Feature", session.Tag, session.Interrupted)
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FindOverlaps returns the logged entries whose time span overlaps [start, end)
+func FindOverlaps(start, end time.Time) ([]LogEntry, error) {
+	// Anything overlapping the range ends after start. Look a month past the
+	// end to catch entries that started inside the range but ended much later.
+	var entries []LogEntry
+	err := withStore(func(store Store) error {
+		var err error
+		entries, err = collect(store.Entries(LogQuery{From: start, To: end.AddDate(0, 1, 0)}))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var overlaps []LogEntry
+	for _, entry := range entries {
+		if entry.StartTime.Before(end) && start.Before(entry.EndTime) {
+			overlaps = append(overlaps, entry)
+		}
+	}
+
+	sort.Slice(overlaps, func(i, j int) bool {
+		return overlaps[i].StartTime.Before(overlaps[j].StartTime)
+	})
+	return overlaps, nil
+}
+
+// AddLogEntry validates a manually entered session and logs it. Unless force
+// is set, it refuses to log a session that overlaps an existing entry and
+// returns the overlapping entries alongside the error.
+func AddLogEntry(entry LogEntry, force bool) (LogEntry, []LogEntry, error) {
+	if err := validateEntryTimes(&entry); err != nil {
+		return entry, nil, err
+	}
+	if entry.EndTime.After(time.Now()) {
+		return entry, nil, fmt.Errorf("end time %s is in the future", entry.EndTime.Format("2006-01-02 15:04"))
+	}
+
+	if !force {
+		overlaps, err := FindOverlaps(entry.StartTime, entry.EndTime)
+		if err != nil {
+			return entry, nil, err
+		}
+		if len(overlaps) > 0 {
+			return entry, overlaps, fmt.Errorf("session overlaps %d logged session(s)", len(overlaps))
+		}
+	}
+
+	if entry.ID == "" {
+		entry.ID = NewID(entry.StartTime)
+	}
+	if entry.Status == "" {
+		entry.Status = StatusManual
+	}
+	return entry, nil, appendSession(entry)
+}
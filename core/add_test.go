@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddLogEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	existing := LogEntry{Tag: "meeting prep", StartTime: at(10, 0), EndTime: at(11, 0), Duration: time.Hour}
+	if err := LogSession(existing); err != nil {
+		t.Fatalf("LogSession() error = %v", err)
+	}
+
+	t.Run("non-overlapping entry is logged", func(t *testing.T) {
+		entry, overlaps, err := AddLogEntry(LogEntry{Tag: "reading", StartTime: at(8, 0), EndTime: at(10, 0), TotalPaused: 15 * time.Minute}, false)
+		if err != nil {
+			t.Fatalf("AddLogEntry() error = %v (overlaps %v)", err, overlaps)
+		}
+		if entry.ID == "" {
+			t.Error("Expected the added entry to get an ID")
+		}
+		if entry.Duration != 105*time.Minute {
+			t.Errorf("Expected duration 1h45m, got %v", entry.Duration)
+		}
+	})
+
+	t.Run("overlapping entry is refused", func(t *testing.T) {
+		_, overlaps, err := AddLogEntry(LogEntry{Tag: "writing", StartTime: at(10, 30), EndTime: at(12, 0)}, false)
+		if err == nil {
+			t.Fatal("Expected an error for an overlapping entry")
+		}
+		if len(overlaps) != 1 || overlaps[0].Tag != "meeting prep" {
+			t.Errorf("Expected the overlap to be 'meeting prep', got %+v", overlaps)
+		}
+	})
+
+	t.Run("overlapping entry is logged with force", func(t *testing.T) {
+		if _, _, err := AddLogEntry(LogEntry{Tag: "writing", StartTime: at(10, 30), EndTime: at(12, 0)}, true); err != nil {
+			t.Fatalf("AddLogEntry(force) error = %v", err)
+		}
+	})
+
+	t.Run("invalid entries are refused", func(t *testing.T) {
+		if _, _, err := AddLogEntry(LogEntry{Tag: "backwards", StartTime: at(15, 0), EndTime: at(14, 0)}, false); err == nil {
+			t.Error("Expected an error when the end is before the start")
+		}
+		future := time.Now().Add(time.Hour)
+		if _, _, err := AddLogEntry(LogEntry{Tag: "future", StartTime: future, EndTime: future.Add(time.Hour)}, false); err == nil {
+			t.Error("Expected an error for a session in the future")
+		}
+	})
+
+	overlaps, err := FindOverlaps(at(9, 0), at(10, 45))
+	if err != nil {
+		t.Fatalf("FindOverlaps() error = %v", err)
+	}
+	if len(overlaps) != 3 {
+		t.Errorf("Expected 3 overlapping entries, got %d", len(overlaps))
+	}
+	// Touching boundaries are not overlaps
+	if overlaps, _ := FindOverlaps(at(12, 0), at(13, 0)); len(overlaps) != 0 {
+		t.Errorf("Expected no overlaps for an adjacent range, got %+v", overlaps)
+	}
+}
@@ -0,0 +1,362 @@
+package core
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archiveSuffix marks a monthly log file that has been compressed with gzip
+const archiveSuffix = ".gz"
+
+// RetentionPolicy archives or deletes old monthly log files automatically
+// the first time a session is logged each day. Ages are spans like "12mo", "2y" or "90d";
+// an empty age leaves files alone.
+type RetentionPolicy struct {
+	ArchiveAfter string `yaml:"archive_after"`
+	DeleteAfter  string `yaml:"delete_after"`
+}
+
+// IsZero reports whether the policy does nothing
+func (p RetentionPolicy) IsZero() bool {
+	return p.ArchiveAfter == "" && p.DeleteAfter == ""
+}
+
+// ArchivedFile describes a monthly log file archived or deleted by ArchiveLogs
+type ArchivedFile struct {
+	Name string
+	// Size is the file's size before, and Archived its size after compression
+	Size     int64
+	Archived int64
+}
+
+// ArchiveReport summarises a run of ArchiveLogs
+type ArchiveReport struct {
+	Archived []ArchivedFile
+	Deleted  []ArchivedFile
+}
+
+// isArchive reports whether a log file is compressed
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, archiveSuffix)
+}
+
+// ParseAge parses a span of days or longer, like "12mo", "2y", "90d" or
+// "6 months", into the time that long before now. Shorter spans such as "12m",
+// which would be minutes, are refused so they can't expire every file.
+func ParseAge(age string, now time.Time) (time.Time, error) {
+	m := relativeSpan.FindStringSubmatch(strings.Join(strings.Fields(strings.ToLower(age)), " "))
+	if m == nil || m[2][0] == 'h' {
+		if _, err := time.ParseDuration(strings.TrimSpace(age)); err == nil {
+			return time.Time{}, fmt.Errorf("invalid age %q: ages are in days or longer (use a span like 12mo for twelve months, 2y or 90d)", age)
+		}
+		return time.Time{}, fmt.Errorf("invalid age %q (use a span like 12mo, 2y or 90d)", age)
+	}
+	start, _, err := parsePeriod(m[0], now)
+	return start, err
+}
+
+// openLogFile opens a log file for reading, decompressing archived ones
+func openLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil || !isArchive(path) {
+		return file, err
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, closeBoth{reader, file}}, nil
+}
+
+// closeBoth closes a decompressor and the file under it
+type closeBoth struct {
+	reader *gzip.Reader
+	file   *os.File
+}
+
+func (c closeBoth) Close() error {
+	err := c.reader.Close()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ArchiveLogs compresses the monthly session logs of months that ended before
+// archiveBefore, and deletes those of months that ended before deleteBefore.
+// A zero time skips that step. Nothing is changed in a dry run.
+func ArchiveLogs(archiveBefore, deleteBefore time.Time, dryRun bool) (ArchiveReport, error) {
+	var report ArchiveReport
+	config, err := LoadConfig()
+	if err != nil {
+		return report, err
+	}
+	if config.Storage != "" && config.Storage != StorageJSONL {
+		return report, fmt.Errorf("archiving only applies to the %s storage backend", StorageJSONL)
+	}
+	store, err := NewJSONLStore()
+	if err != nil {
+		return report, err
+	}
+	return store.(*jsonlStore).archive(archiveBefore, deleteBefore, dryRun)
+}
+
+// ApplyRetention archives and deletes old log files as the configured
+// retention policy asks, never deleting the month holding keep. Only the
+// JSONL backend keeps monthly files, so the policy is ignored with other
+// backends.
+func ApplyRetention(keep time.Time) (ArchiveReport, error) {
+	config, err := LoadConfig()
+	if err != nil || config.Retention.IsZero() || (config.Storage != "" && config.Storage != StorageJSONL) {
+		return ArchiveReport{}, err
+	}
+
+	now := time.Now()
+	var archiveBefore, deleteBefore time.Time
+	if config.Retention.ArchiveAfter != "" {
+		if archiveBefore, err = ParseAge(config.Retention.ArchiveAfter, now); err != nil {
+			return ArchiveReport{}, fmt.Errorf("retention.archive_after: %w", err)
+		}
+	}
+	if config.Retention.DeleteAfter != "" {
+		if deleteBefore, err = ParseAge(config.Retention.DeleteAfter, now); err != nil {
+			return ArchiveReport{}, fmt.Errorf("retention.delete_after: %w", err)
+		}
+		if month := time.Date(keep.Year(), keep.Month(), 1, 0, 0, 0, 0, time.Local); !keep.IsZero() && month.Before(deleteBefore) {
+			deleteBefore = month
+		}
+	}
+	return ArchiveLogs(archiveBefore, deleteBefore, false)
+}
+
+// applyRetentionDaily applies the retention policy if it hasn't been applied
+// on this device today, never deleting the month holding keep
+func applyRetentionDaily(keep time.Time) error {
+	config, err := LoadConfig()
+	if err != nil || config.Retention.IsZero() || (config.Storage != "" && config.Storage != StorageJSONL) {
+		return err
+	}
+	path, err := retentionCheckPath()
+	if err != nil {
+		return err
+	}
+	today := time.Now().Format("2006-01-02")
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) == today {
+		return nil
+	}
+	if _, err := ApplyRetention(keep); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(today+"\n"), 0644)
+}
+
+// retentionCheckPath returns the file recording the day the retention policy
+// was last applied, which in sync mode is kept per device
+func retentionCheckPath() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	name := "retention"
+	if device := syncDevice(); device != "" {
+		name += "." + device
+	}
+	return filepath.Join(dataDir, name), nil
+}
+
+// archive compresses and deletes the store's monthly files, oldest first
+func (s *jsonlStore) archive(archiveBefore, deleteBefore time.Time, dryRun bool) (ArchiveReport, error) {
+	var report ArchiveReport
+	files, err := s.logFiles(time.Time{}, time.Time{})
+	if err != nil {
+		return report, err
+	}
+	sort.Strings(files)
+
+	unlock, err := lockLogs()
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	indexed := make(map[string]*monthIndex)
+	for _, path := range files {
+		month, device, ok := parseLogFileName(path)
+		// In sync mode, each device archives only the files it writes
+		if !ok || device != s.device {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return report, err
+		}
+		file := ArchivedFile{Name: filepath.Base(path), Size: info.Size()}
+		end := month.AddDate(0, 1, 0)
+
+		switch {
+		case !deleteBefore.IsZero() && !end.After(deleteBefore):
+			report.Deleted = append(report.Deleted, file)
+			if dryRun {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return report, err
+			}
+			indexed[file.Name] = nil
+		case !archiveBefore.IsZero() && !end.After(archiveBefore) && !isArchive(path):
+			if dryRun {
+				report.Archived = append(report.Archived, file)
+				continue
+			}
+			archived, err := compressLogFile(path)
+			if err != nil {
+				return report, err
+			}
+			if info, err := os.Stat(archived); err == nil {
+				file.Archived = info.Size()
+			}
+			report.Archived = append(report.Archived, file)
+			indexed[file.Name] = nil
+			if summary, err := summarizeLogFile(archived); err == nil {
+				indexed[filepath.Base(archived)] = summary
+			}
+		}
+	}
+
+	// Release the log lock before taking the index lock, which writers take first
+	unlock()
+	if len(indexed) > 0 {
+		s.storeIndexMonths(indexed)
+	}
+	return report, nil
+}
+
+// compressLogFile moves a monthly log into its compressed archive, adding to
+// any archive the month already has, and returns the archive's path. The
+// caller holds the log lock.
+func compressLogFile(path string) (string, error) {
+	archived := path + archiveSuffix
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "temp_archive_")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		// The temp file is gone once it has been renamed over the archive
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	writer := gzip.NewWriter(tempFile)
+	copyFrom := func(source string) error {
+		reader, err := openLogFile(source)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		_, err = io.Copy(writer, reader)
+		return err
+	}
+	if _, err := os.Stat(archived); err == nil {
+		if err := copyFrom(archived); err != nil {
+			_ = tempFile.Close()
+			return "", err
+		}
+	}
+	if err := copyFrom(path); err != nil {
+		_ = tempFile.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		_ = tempFile.Close()
+		return "", err
+	}
+	if err := tempFile.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return "", err
+	}
+
+	// Only remove the plain file once the archive holding its entries is in place
+	if err := os.Rename(tempFile.Name(), archived); err != nil {
+		return "", err
+	}
+	return archived, os.Remove(path)
+}
+
+// unarchive moves an archived month back into its plain monthly file, so its
+// entries can be edited or deleted in place. Entries already in the plain
+// file are kept after the archived ones.
+func (s *jsonlStore) unarchive(path string) error {
+	archived := path + archiveSuffix
+	if _, err := os.Stat(archived); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	unlock, err := lockLogs()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "temp_log_")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The temp file is gone once it has been renamed over the log file
+		_ = os.Remove(tempFile.Name())
+	}()
+
+	writer := bufio.NewWriter(tempFile)
+	for _, source := range []string{archived, path} {
+		reader, err := openLogFile(source)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			_ = tempFile.Close()
+			return err
+		}
+		_, err = io.Copy(writer, reader)
+		_ = reader.Close()
+		if err != nil {
+			_ = tempFile.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return err
+	}
+	if err := os.Remove(archived); err != nil {
+		return err
+	}
+	unlock()
+	s.storeIndexMonths(map[string]*monthIndex{filepath.Base(archived): nil})
+	return nil
+}
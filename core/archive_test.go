@@ -0,0 +1,154 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveLogs(t *testing.T) {
+	store := openTestStore(t, StorageJSONL)
+
+	logged := func(year int, month time.Month, day int, tag string) LogEntry {
+		end := time.Date(year, month, day, 10, 0, 0, 0, time.Local)
+		entry := LogEntry{Tag: tag, StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour}
+		if err := LogSession(entry); err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	logged(2023, 5, 10, "Deleted")
+	logged(2024, 1, 10, "January")
+	logged(2024, 1, 20, "Late January")
+	logged(2024, 2, 10, "February")
+	logged(2025, 6, 10, "Recent")
+
+	logDir, err := GetLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(logDir, name))
+		return err == nil
+	}
+
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	deleteBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	report, err := ArchiveLogs(cutoff, deleteBefore, true)
+	if err != nil || len(report.Archived) != 2 || len(report.Deleted) != 1 || !exists("202401_sessions.jsonl") {
+		t.Fatalf("ArchiveLogs(dry run) = %+v, %v", report, err)
+	}
+
+	report, err = ArchiveLogs(cutoff, deleteBefore, false)
+	if err != nil || len(report.Archived) != 2 || len(report.Deleted) != 1 {
+		t.Fatalf("ArchiveLogs() = %+v, %v", report, err)
+	}
+	if exists("202305_sessions.jsonl") || exists("202401_sessions.jsonl") || !exists("202401_sessions.jsonl.gz") || !exists("202506_sessions.jsonl") {
+		t.Error("Expected the old months to be deleted and archived, and the recent one left alone")
+	}
+
+	// A session added to an archived month sits in a plain file beside the archive
+	logged(2024, 1, 15, "Added later")
+	tags := func() string {
+		t.Helper()
+		entries, err := collect(store.Entries(LogQuery{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := ""
+		for _, entry := range entries {
+			result += entry.Tag + ";"
+		}
+		return result
+	}
+	if got := tags(); got != "Recent;February;Late January;Added later;January;" {
+		t.Errorf("Entries() with archives = %q", got)
+	}
+	if summary, err := store.Summarize(LogQuery{}); err != nil || summary.Total.Count != 5 {
+		t.Errorf("Summarize() with archives = %+v, %v", summary, err)
+	}
+
+	// Deleting from an archived month unpacks it
+	entries, err := collect(store.Entries(LogQuery{Where: func(entry LogEntry) bool { return entry.Tag == "January" }}))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Entries(January) = %+v, %v", entries, err)
+	}
+	if err := DeleteLogEntry(entries[0]); err != nil {
+		t.Fatalf("DeleteLogEntry() in an archived month error = %v", err)
+	}
+	if exists("202401_sessions.jsonl.gz") {
+		t.Error("Expected the archive to be unpacked")
+	}
+	if got := tags(); got != "Recent;February;Late January;Added later;" {
+		t.Errorf("Entries() after delete = %q", got)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	for age, want := range map[string]time.Time{
+		"12mo":     now.AddDate(-1, 0, 0),
+		"2y":       now.AddDate(-2, 0, 0),
+		"90 days":  now.AddDate(0, 0, -90),
+		"6 months": now.AddDate(0, -6, 0),
+	} {
+		if got, err := ParseAge(age, now); err != nil || !got.Equal(want) {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", age, got, err, want)
+		}
+	}
+	// Spans shorter than a day, like "12m" for minutes, are refused
+	for _, age := range []string{"", "2024-01", "yesterday", "soon", "12m", "3h", "90s", "2 hours"} {
+		if _, err := ParseAge(age, now); err == nil {
+			t.Errorf("ParseAge(%q) should fail", age)
+		}
+	}
+}
+
+func TestRetention(t *testing.T) {
+	openTestStore(t, StorageJSONL)
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureDir(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("retention:\n  delete_after: 1y\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logDir, err := GetLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(logDir, name))
+		return err == nil
+	}
+	entry := func(end time.Time, tag string) LogEntry {
+		return LogEntry{Tag: tag, StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour}
+	}
+	old := time.Date(2020, 3, 10, 10, 0, 0, 0, time.Local)
+
+	// Backfilling an old session doesn't apply the policy
+	if _, _, err := AddLogEntry(entry(old, "Backfilled"), false); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("202003_sessions.jsonl") {
+		t.Fatal("Expected a backfilled session to be kept")
+	}
+
+	// Logging a session applies it once a day, sparing the month just logged to
+	if err := LogSession(entry(old.AddDate(0, 1, 0), "Stale")); err != nil {
+		t.Fatal(err)
+	}
+	if exists("202003_sessions.jsonl") || !exists("202004_sessions.jsonl") {
+		t.Error("Expected old months but the one just logged to to be deleted")
+	}
+	if err := LogSession(entry(old, "Again")); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("202003_sessions.jsonl") {
+		t.Error("Expected the policy to be applied only once a day")
+	}
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-yaml"
+)
+
+// DirConfigName is the per-directory config file whose auto-tag rules take
+// precedence over the global ones for sessions started at or below it
+const DirConfigName = ".flow.yml"
+
+// TagRule derives a session's tag and project from where it is started. Every
+// condition given must match: Dir and Branch are regular expressions matched
+// against the working directory and git branch, and Env maps environment
+// variables to regular expressions their values must match. Tag and Project
+// are templates, e.g. "JIRA-{{.Branch | issue}}".
+type TagRule struct {
+	Dir     string            `yaml:"dir"`
+	Branch  string            `yaml:"branch"`
+	Env     map[string]string `yaml:"env"`
+	Tag     string            `yaml:"tag"`
+	Project string            `yaml:"project"`
+	Labels  []string          `yaml:"labels"`
+}
+
+// TagContext is the data available to tag rule templates
+type TagContext struct {
+	Dir    string
+	Repo   string
+	Branch string
+	Remote string
+	// Match holds the named groups captured by the rule's regular expressions
+	Match map[string]string
+}
+
+// issuePattern matches issue keys such as JIRA-123
+var issuePattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-\d+`)
+
+// tagFuncs are the functions available to tag rule templates
+var tagFuncs = template.FuncMap{
+	"issue": func(s string) string { return issuePattern.FindString(strings.ToUpper(s)) },
+	"base":  filepath.Base,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"env":   os.Getenv,
+}
+
+// LoadDirRules reads the auto-tag rules from the .flow.yml files in dir and
+// its parents, nearest first
+func LoadDirRules(dir string) ([]TagRule, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []TagRule
+	for {
+		path := filepath.Join(dir, DirConfigName)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var dirCfg struct {
+				AutoTag []TagRule `yaml:"auto_tag"`
+			}
+			if err := yaml.Unmarshal(data, &dirCfg); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			rules = append(rules, dirCfg.AutoTag...)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return rules, nil
+		}
+		dir = parent
+	}
+}
+
+// TagRules returns the auto-tag rules that apply in dir: those from .flow.yml
+// files in dir and its parents, nearest first, then the global ones
+func (c *Config) TagRules(dir string) ([]TagRule, error) {
+	rules, err := LoadDirRules(dir)
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, c.AutoTag...), nil
+}
+
+// MatchTagRule returns the first rule matching where a session is started,
+// with its tag and project templates rendered. Broken rules are skipped, so
+// one bad rule doesn't turn off those after it, and reported in the error,
+// which may come with a match.
+func MatchTagRule(rules []TagRule, git *GitContext) (TagRule, bool, error) {
+	ctx := TagContext{Match: map[string]string{}}
+	if git != nil {
+		ctx.Dir, ctx.Repo, ctx.Branch, ctx.Remote = git.Dir, git.Repo(), git.Branch, git.Remote
+	}
+
+	var errs []error
+	for i, rule := range rules {
+		ctx.Match = map[string]string{}
+		matched, err := rule.matches(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("auto_tag rule %d: %w", i+1, err))
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		if rule.Tag, err = renderTagTemplate(rule.Tag, ctx); err != nil {
+			errs = append(errs, fmt.Errorf("auto_tag rule %d: tag: %w", i+1, err))
+			continue
+		}
+		if rule.Project, err = renderTagTemplate(rule.Project, ctx); err != nil {
+			errs = append(errs, fmt.Errorf("auto_tag rule %d: project: %w", i+1, err))
+			continue
+		}
+		return rule, true, errors.Join(errs...)
+	}
+	return TagRule{}, false, errors.Join(errs...)
+}
+
+// matches reports whether every condition of the rule holds, recording named
+// captures in ctx.Match
+func (r TagRule) matches(ctx TagContext) (bool, error) {
+	if r.Dir == "" && r.Branch == "" && len(r.Env) == 0 {
+		return false, fmt.Errorf("no dir, branch or env condition")
+	}
+
+	check := func(pattern, value string) (bool, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		match := re.FindStringSubmatch(value)
+		if match == nil {
+			return false, nil
+		}
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				ctx.Match[name] = match[i]
+			}
+		}
+		return true, nil
+	}
+
+	if r.Dir != "" {
+		if ok, err := check(r.Dir, ctx.Dir); !ok || err != nil {
+			return false, err
+		}
+	}
+	if r.Branch != "" {
+		if ctx.Branch == "" {
+			return false, nil
+		}
+		if ok, err := check(r.Branch, ctx.Branch); !ok || err != nil {
+			return false, err
+		}
+	}
+	for name, pattern := range r.Env {
+		value, set := os.LookupEnv(name)
+		if !set {
+			return false, nil
+		}
+		if ok, err := check(pattern, value); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// renderTagTemplate executes a tag or project template against ctx
+func renderTagTemplate(text string, ctx TagContext) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("tag").Funcs(tagFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
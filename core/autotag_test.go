@@ -0,0 +1,102 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchTagRule(t *testing.T) {
+	t.Setenv("FLOW_TEST_CLIENT", "acme")
+	git := &GitContext{Dir: "/src/mono/services/billing", Root: "/src/mono", Branch: "feature/pay-142-refunds"}
+
+	tests := []struct {
+		name        string
+		rules       []TagRule
+		wantOK      bool
+		wantTag     string
+		wantProject string
+	}{
+		{
+			name:    "issue from branch",
+			rules:   []TagRule{{Branch: `^feature/`, Tag: "JIRA-{{.Branch | issue}}"}},
+			wantOK:  true,
+			wantTag: "JIRA-PAY-142",
+		},
+		{
+			name:        "named capture from directory",
+			rules:       []TagRule{{Dir: `/services/(?P<service>[^/]+)`, Tag: "Work on {{.Match.service}}", Project: "{{.Repo}}/{{.Match.service}}"}},
+			wantOK:      true,
+			wantTag:     "Work on billing",
+			wantProject: "mono/billing",
+		},
+		{
+			name:    "first match wins",
+			rules:   []TagRule{{Branch: `^main$`, Tag: "Main"}, {Env: map[string]string{"FLOW_TEST_CLIENT": "^acme$"}, Tag: "Client {{env \"FLOW_TEST_CLIENT\"}}"}},
+			wantOK:  true,
+			wantTag: "Client acme",
+		},
+		{
+			name:   "all conditions must match",
+			rules:  []TagRule{{Dir: `^/src/mono`, Env: map[string]string{"FLOW_TEST_UNSET": ".*"}, Tag: "Nope"}},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok, err := MatchTagRule(tt.rules, git)
+			if err != nil {
+				t.Fatalf("MatchTagRule() error = %v", err)
+			}
+			if ok != tt.wantOK || rule.Tag != tt.wantTag || rule.Project != tt.wantProject {
+				t.Errorf("MatchTagRule() = %q, %q, %v; want %q, %q, %v",
+					rule.Tag, rule.Project, ok, tt.wantTag, tt.wantProject, tt.wantOK)
+			}
+		})
+	}
+
+	if _, _, err := MatchTagRule([]TagRule{{Branch: `(`, Tag: "Broken"}}, git); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+	if _, _, err := MatchTagRule([]TagRule{{Tag: "Always"}}, git); err == nil {
+		t.Error("Expected an error for a rule without conditions")
+	}
+
+	// Broken rules are reported without stopping later rules from matching
+	broken := []TagRule{{Branch: `(`, Tag: "Broken"}, {Tag: "Always"}, {Dir: `^/src/mono`, Tag: "Mono"}}
+	rule, ok, err := MatchTagRule(broken, git)
+	if !ok || rule.Tag != "Mono" || err == nil || !strings.Contains(err.Error(), "rule 1") || !strings.Contains(err.Error(), "rule 2") {
+		t.Errorf("MatchTagRule() after broken rules = %+v, %v, %v", rule, ok, err)
+	}
+}
+
+func TestTagRulesDirectoryOverride(t *testing.T) {
+	root := t.TempDir()
+	service := filepath.Join(root, "services", "billing")
+	if err := os.MkdirAll(service, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(root, DirConfigName), "auto_tag:\n  - dir: \".*\"\n    tag: \"Monorepo\"\n")
+	writeFile(filepath.Join(service, DirConfigName), "auto_tag:\n  - dir: \".*\"\n    tag: \"Billing\"\n    project: \"mono/billing\"\n")
+
+	config := Config{AutoTag: []TagRule{{Dir: ".*", Tag: "Global"}}}
+	rules, err := config.TagRules(service)
+	if err != nil {
+		t.Fatalf("TagRules() error = %v", err)
+	}
+	if len(rules) != 3 || rules[0].Tag != "Billing" || rules[1].Tag != "Monorepo" || rules[2].Tag != "Global" {
+		t.Fatalf("TagRules() = %+v, want nearest .flow.yml first", rules)
+	}
+
+	rule, ok, err := MatchTagRule(rules, &GitContext{Dir: service})
+	if err != nil || !ok || rule.Tag != "Billing" || rule.Project != "mono/billing" {
+		t.Errorf("MatchTagRule() = %+v, %v, %v", rule, ok, err)
+	}
+}
@@ -0,0 +1,719 @@
+package core
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// backupManifestName is the manifest's name inside a backup archive
+	backupManifestName = "manifest.json"
+	// backupVersion changes whenever the backup layout does
+	backupVersion = 1
+)
+
+// Ways of restoring a backup
+const (
+	RestoreReplace = "replace"
+	RestoreMerge   = "merge"
+)
+
+// Actions a restore takes on a file
+const (
+	RestoreAdd       = "add"
+	RestoreOverwrite = "replace"
+	RestoreRemove    = "remove"
+	RestoreAppend    = "merge"
+	RestoreKeep      = "keep"
+	RestoreUnchanged = "unchanged"
+)
+
+// BackupFile describes a file held in a backup
+type BackupFile struct {
+	Path   string      `json:"path"`
+	Size   int64       `json:"size"`
+	Mode   fs.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+}
+
+// BackupManifest lists the contents of a backup, with checksums to validate
+// them by when it is restored
+type BackupManifest struct {
+	Version       int          `json:"version"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Host          string       `json:"host,omitempty"`
+	Storage       string       `json:"storage"`
+	SchemaVersion int          `json:"schema_version"`
+	Files         []BackupFile `json:"files"`
+}
+
+// RestoreChange is what a restore does to one file
+type RestoreChange struct {
+	Path   string
+	Action string
+}
+
+// RestorePlan describes what restoring a backup changes. Sessions are
+// compared by ID: Added are in the backup only, Removed in the current data
+// only, and Unchanged in both.
+type RestorePlan struct {
+	Mode      string
+	Changes   []RestoreChange
+	Added     int
+	Removed   int
+	Unchanged int
+	// Safety is the backup of the current data taken before restoring
+	Safety string
+}
+
+// Backup is a validated backup, unpacked into a temporary directory
+type Backup struct {
+	Manifest BackupManifest
+	dir      string
+}
+
+// backupRoots are the locations the files of a backup are restored to
+type backupRoots struct {
+	logDir      string
+	dbPath      string
+	sessionPath string
+	configDir   string
+}
+
+func getBackupRoots() (backupRoots, error) {
+	var roots backupRoots
+	var err error
+	if roots.logDir, err = GetLogDir(); err != nil {
+		return roots, err
+	}
+	if roots.dbPath, err = GetDatabasePath(); err != nil {
+		return roots, err
+	}
+	if roots.sessionPath, err = GetSessionPath(); err != nil {
+		return roots, err
+	}
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return roots, err
+	}
+	roots.configDir = filepath.Dir(configPath)
+	return roots, nil
+}
+
+// local returns where a file of a backup belongs, reporting false for paths
+// a backup doesn't hold
+func (r backupRoots) local(name string) (string, bool) {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || strings.Contains(name, "\\") {
+		return "", false
+	}
+	switch name {
+	case "data/flow.db":
+		return r.dbPath, true
+	case "data/flow.db-wal":
+		return r.dbPath + "-wal", true
+	case "data/session":
+		return r.sessionPath, true
+	case "config/config.yml":
+		return filepath.Join(r.configDir, "config.yml"), true
+	}
+	if rest, ok := strings.CutPrefix(name, "data/logs/"); ok && !strings.Contains(rest, "/") && !skipBackup(rest) {
+		return filepath.Join(r.logDir, rest), true
+	}
+	if rest, ok := strings.CutPrefix(name, "config/hooks/"); ok && !strings.HasPrefix(rest, "../") && rest != ".." {
+		return filepath.Join(r.configDir, "hooks", filepath.FromSlash(rest)), true
+	}
+	return "", false
+}
+
+// sources returns the files there are to back up, by their path in a backup
+func (r backupRoots) sources() (map[string]string, error) {
+	sources := make(map[string]string)
+	logs, err := os.ReadDir(r.logDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range logs {
+		if entry.Type().IsRegular() && !skipBackup(entry.Name()) {
+			sources["data/logs/"+entry.Name()] = filepath.Join(r.logDir, entry.Name())
+		}
+	}
+
+	for _, name := range []string{"data/flow.db", "data/flow.db-wal", "data/session", "config/config.yml"} {
+		local, _ := r.local(name)
+		if info, err := os.Stat(local); err == nil && info.Mode().IsRegular() {
+			sources[name] = local
+		}
+	}
+
+	hooksDir := filepath.Join(r.configDir, "hooks")
+	err = filepath.WalkDir(hooksDir, func(local string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(hooksDir, local)
+		if err != nil {
+			return err
+		}
+		sources["config/hooks/"+filepath.ToSlash(rel)] = local
+		return nil
+	})
+	return sources, err
+}
+
+// skipBackup reports whether a file in the log directory is left out of
+// backups: locks, temporary files and summary indexes, which are rebuilt
+func skipBackup(name string) bool {
+	return strings.HasSuffix(name, ".lock") || strings.HasPrefix(name, ".") ||
+		strings.HasPrefix(name, "temp_") || isIndexFile(name)
+}
+
+// fileSHA256 returns the hex SHA-256 checksum of a file
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DefaultBackupName is the file name a backup taken now is given
+func DefaultBackupName(now time.Time) string {
+	return "flow-backup-" + now.Format("20060102-150405") + ".tar.gz"
+}
+
+// CreateBackup writes the session file, the logs, the database, the config
+// file and the hooks to a gzipped tar archive at output, led by a manifest
+// with their checksums. An existing file is not overwritten.
+func CreateBackup(output string) (BackupManifest, error) {
+	manifest := BackupManifest{Version: backupVersion, CreatedAt: time.Now(), SchemaVersion: CurrentSchemaVersion, Storage: StorageJSONL}
+	manifest.Host, _ = os.Hostname()
+	config, err := LoadConfig()
+	if err != nil {
+		return manifest, err
+	}
+	if config.Storage != "" {
+		manifest.Storage = config.Storage
+	}
+	if _, err := os.Stat(output); err == nil {
+		return manifest, fmt.Errorf("%s already exists", output)
+	}
+
+	roots, err := getBackupRoots()
+	if err != nil {
+		return manifest, err
+	}
+	sources, err := roots.sources()
+	if err != nil {
+		return manifest, err
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Checksum every file first, since the manifest leads the archive
+	for _, name := range names {
+		info, err := os.Stat(sources[name])
+		if err != nil {
+			return manifest, err
+		}
+		sum, err := fileSHA256(sources[name])
+		if err != nil {
+			return manifest, err
+		}
+		manifest.Files = append(manifest.Files, BackupFile{Path: name, Size: info.Size(), Mode: info.Mode().Perm(), SHA256: sum})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+
+	if err := ensureDir(output); err != nil {
+		return manifest, err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(output), ".flow-backup-")
+	if err != nil {
+		return manifest, err
+	}
+	defer func() {
+		// The temp file is gone once it has been renamed to the output
+		_ = os.Remove(tempFile.Name())
+	}()
+	writeArchive := func() error {
+		gz := gzip.NewWriter(tempFile)
+		tw := tar.NewWriter(gz)
+		header := &tar.Header{Name: backupManifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+		for _, file := range manifest.Files {
+			if err := addBackupFile(tw, file, sources[file.Path]); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+	if err := writeArchive(); err != nil {
+		_ = tempFile.Close()
+		return manifest, err
+	}
+	if err := tempFile.Close(); err != nil {
+		return manifest, err
+	}
+	if err := os.Chmod(tempFile.Name(), 0600); err != nil {
+		return manifest, err
+	}
+	return manifest, os.Rename(tempFile.Name(), output)
+}
+
+// addBackupFile writes a file to the archive, checking it hasn't changed
+// since its checksum was taken
+func addBackupFile(tw *tar.Writer, file BackupFile, source string) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != file.SHA256 {
+		return fmt.Errorf("%s changed while it was backed up", source)
+	}
+	header := &tar.Header{Name: file.Path, Mode: int64(file.Mode), Size: int64(len(content)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = tw.Write(content)
+	return err
+}
+
+// OpenBackup unpacks a backup archive into a temporary directory, checking
+// every file against the manifest. Close removes the unpacked files.
+func OpenBackup(archive string) (*Backup, error) {
+	dir, err := os.MkdirTemp("", "flow-restore-")
+	if err != nil {
+		return nil, err
+	}
+	backup := &Backup{dir: dir}
+	if err := backup.unpack(archive); err != nil {
+		_ = backup.Close()
+		return nil, fmt.Errorf("invalid backup %s: %w", archive, err)
+	}
+	return backup, nil
+}
+
+// Close removes the unpacked backup
+func (b *Backup) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+// unpack extracts and validates the archive
+func (b *Backup) unpack(archive string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	// Only the layout of the paths is checked, so no locations are needed
+	var layout backupRoots
+	sums := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected entry %s", header.Name)
+		}
+		if _, ok := layout.local(header.Name); !ok && header.Name != backupManifestName {
+			return fmt.Errorf("unexpected file %s", header.Name)
+		}
+		if _, ok := sums[header.Name]; ok {
+			return fmt.Errorf("%s appears twice", header.Name)
+		}
+		target := filepath.Join(b.dir, filepath.FromSlash(header.Name))
+		if err := ensureDir(target); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(io.MultiWriter(out, hash), tr)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		sums[header.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if _, ok := sums[backupManifestName]; !ok {
+		return fmt.Errorf("no %s", backupManifestName)
+	}
+	data, err := os.ReadFile(filepath.Join(b.dir, backupManifestName))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return fmt.Errorf("%s: %w", backupManifestName, err)
+	}
+	if b.Manifest.Version < 1 || b.Manifest.Version > backupVersion {
+		return fmt.Errorf("unsupported backup version %d", b.Manifest.Version)
+	}
+	delete(sums, backupManifestName)
+	for _, file := range b.Manifest.Files {
+		sum, ok := sums[file.Path]
+		if !ok {
+			return fmt.Errorf("%s is missing", file.Path)
+		}
+		if sum != file.SHA256 {
+			return fmt.Errorf("%s fails its checksum", file.Path)
+		}
+		delete(sums, file.Path)
+	}
+	for name := range sums {
+		return fmt.Errorf("%s is not in the manifest", name)
+	}
+	return nil
+}
+
+// path returns where a file of the backup was unpacked
+func (b *Backup) path(name string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(name))
+}
+
+// has reports whether the backup holds a file
+func (b *Backup) has(name string) bool {
+	for _, file := range b.Manifest.Files {
+		if file.Path == name {
+			return true
+		}
+	}
+	return false
+}
+
+// store opens the backup's sessions in the storage backend it was taken with
+func (b *Backup) store() (Store, error) {
+	if b.Manifest.Storage == StorageSQLite && b.has("data/flow.db") {
+		return openSQLiteStore(b.path("data/flow.db"))
+	}
+	return &jsonlStore{logDir: b.path("data/logs"), sessionPath: b.path("data/session")}, nil
+}
+
+// isSessionData reports whether a file holds sessions, which are merged
+// entry by entry rather than file by file
+func isSessionData(name string) bool {
+	return strings.HasPrefix(name, "data/flow.db") ||
+		(strings.HasPrefix(name, "data/logs/") && strings.Contains(name, "_sessions."))
+}
+
+// Plan works out what restoring the backup in the given mode would change
+func (b *Backup) Plan(mode string) (RestorePlan, error) {
+	plan, _, err := b.plan(mode)
+	return plan, err
+}
+
+// plan works out the restore, returning the backup's sessions the current
+// data doesn't have, oldest first
+func (b *Backup) plan(mode string) (RestorePlan, []LogEntry, error) {
+	plan := RestorePlan{Mode: mode}
+	if mode != RestoreReplace && mode != RestoreMerge {
+		return plan, nil, fmt.Errorf("unknown restore mode %q", mode)
+	}
+	roots, err := getBackupRoots()
+	if err != nil {
+		return plan, nil, err
+	}
+
+	// Compare the sessions by ID
+	current := make(map[string]bool)
+	err = withStore(func(store Store) error {
+		for entry, err := range store.Entries(LogQuery{Statuses: allStatuses}) {
+			if err != nil {
+				return err
+			}
+			current[entry.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return plan, nil, err
+	}
+	store, err := b.store()
+	if err != nil {
+		return plan, nil, err
+	}
+	var added []LogEntry
+	inBackup := make(map[string]bool)
+	for entry, err := range store.Entries(LogQuery{Statuses: allStatuses, Order: OldestFirst}) {
+		if err != nil {
+			_ = store.Close()
+			return plan, nil, err
+		}
+		if inBackup[entry.ID] {
+			continue
+		}
+		inBackup[entry.ID] = true
+		if current[entry.ID] {
+			plan.Unchanged++
+		} else {
+			added = append(added, entry)
+		}
+	}
+	if err := store.Close(); err != nil {
+		return plan, nil, err
+	}
+	plan.Added = len(added)
+	if mode == RestoreReplace {
+		plan.Removed = len(current) - plan.Unchanged
+	}
+
+	// Compare the files
+	for _, file := range b.Manifest.Files {
+		if mode == RestoreMerge && isSessionData(file.Path) {
+			// Merged entry by entry, counted above
+			continue
+		}
+		local, _ := roots.local(file.Path)
+		sum, err := fileSHA256(local)
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return plan, nil, err
+		}
+		action := RestoreUnchanged
+		switch {
+		case exists && sum == file.SHA256:
+		case mode == RestoreReplace && exists:
+			action = RestoreOverwrite
+		case mode == RestoreReplace || !exists:
+			action = RestoreAdd
+		case strings.HasPrefix(file.Path, "data/logs/"):
+			action = RestoreAppend
+		default:
+			// The current session, config and hooks are kept when merging
+			action = RestoreKeep
+		}
+		plan.Changes = append(plan.Changes, RestoreChange{Path: file.Path, Action: action})
+	}
+	if mode == RestoreReplace {
+		sources, err := roots.sources()
+		if err != nil {
+			return plan, nil, err
+		}
+		for name := range sources {
+			if !b.has(name) {
+				plan.Changes = append(plan.Changes, RestoreChange{Path: name, Action: RestoreRemove})
+			}
+		}
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].Path < plan.Changes[j].Path
+	})
+	return plan, added, nil
+}
+
+// Restore restores the backup into the current data and config. Replacing
+// makes them match the backup exactly; merging adds the backup's sessions
+// that are missing, the lines missing from its other log files, and any
+// files that don't exist yet, keeping everything else. A backup of the
+// current data is taken first, so a restore can be undone.
+func (b *Backup) Restore(mode string) (RestorePlan, error) {
+	plan, added, err := b.plan(mode)
+	if err != nil {
+		return plan, err
+	}
+	roots, err := getBackupRoots()
+	if err != nil {
+		return plan, err
+	}
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return plan, err
+	}
+	// Restores within the same second get numbered safety backups
+	name := "restore-" + time.Now().Format("20060102-150405")
+	plan.Safety = filepath.Join(dataDir, "backups", name+".tar.gz")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(plan.Safety); os.IsNotExist(err) {
+			break
+		}
+		plan.Safety = filepath.Join(dataDir, "backups", fmt.Sprintf("%s-%d.tar.gz", name, n))
+	}
+	if _, err := CreateBackup(plan.Safety); err != nil {
+		return plan, fmt.Errorf("backing up current data: %w", err)
+	}
+
+	if mode == RestoreMerge {
+		err = withStore(func(store Store) error {
+			for _, entry := range added {
+				if err := store.Append(entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	unlock, err := lockLogs()
+	if err != nil {
+		return plan, err
+	}
+	defer unlock()
+	for _, change := range plan.Changes {
+		local, _ := roots.local(change.Path)
+		switch {
+		case change.Action == RestoreRemove:
+			err = os.Remove(local)
+		case change.Action == RestoreAppend:
+			err = mergeLines(b.path(change.Path), local)
+		case change.Action == RestoreAdd || change.Action == RestoreOverwrite:
+			err = b.restoreFile(change.Path, local)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return plan, err
+		}
+	}
+	if mode == RestoreReplace {
+		// The database's shared memory index belongs to the database replaced
+		if err := os.Remove(roots.dbPath + "-shm"); err != nil && !os.IsNotExist(err) {
+			return plan, err
+		}
+	}
+	return plan, nil
+}
+
+// restoreFile atomically puts a file of the backup in place
+func (b *Backup) restoreFile(name, local string) error {
+	mode := fs.FileMode(0644)
+	for _, file := range b.Manifest.Files {
+		if file.Path == name && file.Mode != 0 {
+			mode = file.Mode
+		}
+	}
+	if err := ensureDir(local); err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(local), "temp_restore_")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The temp file is gone once it has been renamed into place
+		_ = os.Remove(tempFile.Name())
+	}()
+	source, err := os.Open(b.path(name))
+	if err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	_, err = io.Copy(tempFile, source)
+	_ = source.Close()
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), local)
+}
+
+// mergeLines appends the lines of from that to doesn't have yet
+func mergeLines(from, to string) error {
+	existing := make(map[string]bool)
+	if err := scanLines(to, func(line string) { existing[line] = true }); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var missing []string
+	if err := scanLines(from, func(line string) {
+		if !existing[line] {
+			existing[line] = true
+			missing = append(missing, line)
+		}
+	}); err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(to, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(strings.Join(missing, "\n") + "\n"); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// scanLines calls fn with each non-blank line of a file
+func scanLines(path string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			fn(line)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupRestore(t *testing.T) {
+	store := openTestStore(t, StorageJSONL)
+
+	logged := func(day int, tag string) LogEntry {
+		end := time.Date(2025, 3, day, 10, 0, 0, 0, time.Local)
+		entry := LogEntry{ID: NewID(end), Tag: tag, StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour, Status: StatusCompleted}
+		if err := LogSession(entry); err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	tags := func() string {
+		t.Helper()
+		entries, err := collect(store.Entries(LogQuery{Order: OldestFirst}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := ""
+		for _, entry := range entries {
+			result += entry.Tag + ";"
+		}
+		return result
+	}
+	first := logged(3, "First")
+	logged(4, "Second")
+
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookPath := filepath.Join(filepath.Dir(configPath), "hooks", "on_start")
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte("storage: jsonl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "backup.tar.gz")
+	manifest, err := CreateBackup(output)
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	var paths []string
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	if got := strings.Join(paths, ","); got != "config/config.yml,config/hooks/on_start,data/logs/202503_sessions.jsonl" {
+		t.Errorf("CreateBackup() files = %s", got)
+	}
+	if _, err := CreateBackup(output); err == nil {
+		t.Error("CreateBackup() should not overwrite an existing file")
+	}
+
+	// Change the data after the backup
+	logged(5, "Third")
+	if err := DeleteLogEntry(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(hookPath); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := OpenBackup(output)
+	if err != nil {
+		t.Fatalf("OpenBackup() error = %v", err)
+	}
+	defer func() {
+		_ = backup.Close()
+	}()
+
+	plan, err := backup.Restore(RestoreMerge)
+	if err != nil || plan.Added != 1 || plan.Unchanged != 1 || plan.Removed != 0 {
+		t.Fatalf("Restore(merge) = %+v, %v", plan, err)
+	}
+	if got := tags(); got != "First;Second;Third;" {
+		t.Errorf("Entries() after merge = %q", got)
+	}
+	if _, err := os.Stat(hookPath); err != nil {
+		t.Error("Expected merging to restore the missing hook")
+	}
+	if _, err := os.Stat(plan.Safety); err != nil {
+		t.Errorf("Expected a safety backup at %s", plan.Safety)
+	}
+
+	plan, err = backup.Plan(RestoreReplace)
+	if err != nil || plan.Added != 0 || plan.Unchanged != 2 || plan.Removed != 1 {
+		t.Fatalf("Plan(replace) = %+v, %v", plan, err)
+	}
+	replaced, err := backup.Restore(RestoreReplace)
+	if err != nil || replaced.Safety == plan.Safety {
+		t.Fatalf("Restore(replace) = %+v, %v", replaced, err)
+	}
+	if got := tags(); got != "First;Second;" {
+		t.Errorf("Entries() after replace = %q", got)
+	}
+}
+
+func TestOpenBackupValidates(t *testing.T) {
+	write := func(files map[string]string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "backup.tar.gz")
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz := gzip.NewWriter(file)
+		tw := tar.NewWriter(gz)
+		for name, content := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// sha256("{}")
+	const sum = "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"
+	for name, files := range map[string]map[string]string{
+		"no manifest":  {"data/session": "{}"},
+		"bad checksum": {"manifest.json": `{"version":1,"files":[{"path":"data/session","sha256":"00"}]}`, "data/session": "{}"},
+		"missing file": {"manifest.json": `{"version":1,"files":[{"path":"data/session","sha256":"` + sum + `"}]}`},
+		"extra file":   {"manifest.json": `{"version":1,"files":[]}`, "data/session": "{}"},
+		"outside":      {"manifest.json": `{"version":1,"files":[]}`, "data/../../evil": "{}"},
+		"newer":        {"manifest.json": `{"version":99,"files":[]}`},
+	} {
+		if backup, err := OpenBackup(write(files)); err == nil {
+			_ = backup.Close()
+			t.Errorf("OpenBackup(%s) should fail", name)
+		}
+	}
+
+	backup, err := OpenBackup(write(map[string]string{
+		"manifest.json": `{"version":1,"files":[{"path":"data/session","sha256":"` + sum + `"}]}`,
+		"data/session":  "{}",
+	}))
+	if err != nil {
+		t.Fatalf("OpenBackup(valid) error = %v", err)
+	}
+	_ = backup.Close()
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CancelRecord is an audit log entry for a session discarded with 'flow cancel'
+type CancelRecord struct {
+	CancelledAt time.Time `json:"cancelled_at"`
+	Session     Session   `json:"session"`
+}
+
+// GetCancelLogPath returns the path to the audit log of cancelled sessions,
+// which in sync mode is this device's own
+func GetCancelLogPath() (string, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(logDir, "cancelled.jsonl")
+	if device := syncDevice(); device != "" {
+		return deviceLogPath(path, device), nil
+	}
+	return path, nil
+}
+
+// CancelSession discards the active session without logging it. When audit is
+// set, the session is recorded in the cancel log so it can be restored later.
+// Cancelling an interruption returns to the session it interrupted, which is
+// returned.
+func CancelSession(session Session, audit bool) (*Session, error) {
+	if audit {
+		path, err := GetCancelLogPath()
+		if err != nil {
+			return nil, err
+		}
+		if err := ensureDir(path); err != nil {
+			return nil, err
+		}
+		// The interrupted sessions stay active, so they aren't part of the record
+		cancelled := session
+		cancelled.Interrupted = nil
+		data, err := json.Marshal(CancelRecord{CancelledAt: time.Now(), Session: cancelled})
+		if err != nil {
+			return nil, err
+		}
+		unlock, err := lockLogs()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.WriteString(string(data) + "\n"); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		if err := file.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return CloseSession(session, time.Now())
+}
+
+// UndoCancel restores the most recently cancelled session and removes it from
+// the cancel log.
+func UndoCancel() (CancelRecord, error) {
+	var record CancelRecord
+	if SessionExists() {
+		return record, fmt.Errorf("a session is already active")
+	}
+
+	path, err := GetCancelLogPath()
+	if err != nil {
+		return record, err
+	}
+	lines, err := readLines(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return record, fmt.Errorf("no cancelled session to restore")
+		}
+		return record, err
+	}
+
+	// Find the last well-formed record
+	last := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if err := json.Unmarshal([]byte(lines[i]), &record); err == nil {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		return record, fmt.Errorf("no cancelled session to restore")
+	}
+
+	if err := SaveSession(record.Session); err != nil {
+		return record, err
+	}
+
+	// Remove that record, counting copies of it in case the line repeats
+	copies := 0
+	for _, line := range lines[:last+1] {
+		if line == lines[last] {
+			copies++
+		}
+	}
+	_, err = rewriteLines(path, func(line string) (string, bool) {
+		if strings.TrimSpace(line) == lines[last] {
+			copies--
+			return line, copies != 0
+		}
+		return line, true
+	})
+	return record, err
+}
+
+// readLines returns the non-empty lines of a file
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
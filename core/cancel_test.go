@@ -0,0 +1,79 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCancelSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	first := Session{Tag: "mistake", StartTime: time.Now().Add(-5 * time.Minute)}
+	second := Session{Tag: "another mistake", StartTime: time.Now().Add(-time.Minute)}
+
+	for _, session := range []Session{first, second} {
+		if err := SaveSession(session); err != nil {
+			t.Fatalf("SaveSession() error = %v", err)
+		}
+		if _, err := CancelSession(session, true); err != nil {
+			t.Fatalf("CancelSession() error = %v", err)
+		}
+		if SessionExists() {
+			t.Fatal("Expected the session file to be removed")
+		}
+	}
+
+	// Nothing is logged
+	reader, _ := NewLogReader()
+	if entries, _ := reader.ReadAllEntries(); len(entries) != 0 {
+		t.Errorf("Expected no log entries after cancelling, got %d", len(entries))
+	}
+
+	// Undo restores the most recent cancellation first
+	record, err := UndoCancel()
+	if err != nil {
+		t.Fatalf("UndoCancel() error = %v", err)
+	}
+	if record.Session.Tag != "another mistake" {
+		t.Errorf("Expected to restore 'another mistake', got %q", record.Session.Tag)
+	}
+	restored, err := LoadSession()
+	if err != nil || restored.Tag != "another mistake" {
+		t.Errorf("Expected restored session to be active, got %+v (err %v)", restored, err)
+	}
+
+	// Can't restore over an active session
+	if _, err := UndoCancel(); err == nil {
+		t.Error("Expected an error when a session is already active")
+	}
+
+	_ = RemoveSession()
+	if record, err := UndoCancel(); err != nil || record.Session.Tag != "mistake" {
+		t.Errorf("Expected to restore 'mistake', got %+v (err %v)", record, err)
+	}
+
+	_ = RemoveSession()
+	if _, err := UndoCancel(); err == nil {
+		t.Error("Expected an error once the cancel log is empty")
+	}
+}
+
+func TestCancelSessionWithoutAudit(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	session := Session{Tag: "gone", StartTime: time.Now()}
+	if err := SaveSession(session); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	if _, err := CancelSession(session, false); err != nil {
+		t.Fatalf("CancelSession() error = %v", err)
+	}
+	if _, err := UndoCancel(); err == nil {
+		t.Error("Expected nothing to restore when the cancellation wasn't audited")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-yaml"
@@ -11,12 +13,32 @@ import (
 
 // Config holds all application configuration.
 type Config struct {
-	StaleSessionThreshold string `yaml:"stale_session_threshold"`
+	StaleSessionThreshold string            `yaml:"stale_session_threshold"`
+	StalePolicy           string            `yaml:"stale_policy"`
+	IdleTimeout           string            `yaml:"idle_timeout"`
+	Presets               map[string]Preset `yaml:"presets"`
+	AutoTag               []TagRule         `yaml:"auto_tag"`
+	Storage               string            `yaml:"storage"`
+	Retention             RetentionPolicy   `yaml:"retention"`
+	Sync                  SyncConfig        `yaml:"sync"`
 	parsedStaleThreshold  time.Duration
+	parsedIdleTimeout     time.Duration
+}
+
+// Preset is a named set of session options, started with 'flow start <name>'
+type Preset struct {
+	Tag      string   `yaml:"tag"`
+	Target   string   `yaml:"target"`
+	Pomodoro string   `yaml:"pomodoro"`
+	Project  string   `yaml:"project"`
+	Labels   []string `yaml:"labels"`
+	// Hooks maps hook events to extra scripts run for sessions of this preset
+	Hooks map[string]string `yaml:"hooks"`
 }
 
 var defaultConfig = Config{
 	StaleSessionThreshold: "8h", // Default to 8 hours
+	StalePolicy:           StalePolicyKeep,
 	parsedStaleThreshold:  8 * time.Hour,
 }
 
@@ -25,6 +47,37 @@ func (c *Config) ParsedStaleSessionThreshold() time.Duration {
 	return c.parsedStaleThreshold
 }
 
+// ParsedIdleTimeout returns how long a session may go without a heartbeat
+// before the gap counts as paused time. Zero disables idle detection.
+func (c *Config) ParsedIdleTimeout() time.Duration {
+	return c.parsedIdleTimeout
+}
+
+// Preset looks up a preset by name. A preset without a tag uses its name.
+func (c *Config) Preset(name string) (Preset, error) {
+	preset, ok := c.Presets[name]
+	if !ok {
+		if len(c.Presets) == 0 {
+			return preset, fmt.Errorf("unknown preset %q (no presets are defined in config.yml)", name)
+		}
+		return preset, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(c.PresetNames(), ", "))
+	}
+	if preset.Tag == "" {
+		preset.Tag = name
+	}
+	return preset, nil
+}
+
+// PresetNames returns the names of the configured presets in sorted order
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Presets))
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // LoadConfig loads the configuration from the YAML file, applying defaults.
 func LoadConfig() (Config, error) {
 	cfg := defaultConfig
@@ -46,7 +99,14 @@ func LoadConfig() (Config, error) {
 
 	// A temporary struct for all user settings to avoid direct manipulation
 	var tempCfg struct {
-		StaleSessionThreshold string `yaml:"stale_session_threshold"`
+		StaleSessionThreshold string            `yaml:"stale_session_threshold"`
+		StalePolicy           string            `yaml:"stale_policy"`
+		IdleTimeout           string            `yaml:"idle_timeout"`
+		Presets               map[string]Preset `yaml:"presets"`
+		AutoTag               []TagRule         `yaml:"auto_tag"`
+		Storage               string            `yaml:"storage"`
+		Retention             RetentionPolicy   `yaml:"retention"`
+		Sync                  SyncConfig        `yaml:"sync"`
 	}
 
 	if err := yaml.Unmarshal(data, &tempCfg); err != nil {
@@ -61,6 +121,23 @@ func LoadConfig() (Config, error) {
 		}
 	}
 
+	for _, policy := range StalePolicies {
+		if tempCfg.StalePolicy == policy {
+			cfg.StalePolicy = policy
+		}
+	}
+	if tempCfg.IdleTimeout != "" {
+		cfg.IdleTimeout = tempCfg.IdleTimeout
+		if d, err := time.ParseDuration(tempCfg.IdleTimeout); err == nil && d > 0 {
+			cfg.parsedIdleTimeout = d
+		}
+	}
+	cfg.Presets = tempCfg.Presets
+	cfg.AutoTag = tempCfg.AutoTag
+	cfg.Storage = tempCfg.Storage
+	cfg.Retention = tempCfg.Retention
+	cfg.Sync = tempCfg.Sync
+
 	return cfg, nil
 }
 
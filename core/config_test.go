@@ -148,3 +148,94 @@ func TestLoadConfig_MalformedYAML(t *testing.T) {
 		t.Fatalf("LoadConfig() should have failed for malformed YAML, but didn't")
 	}
 }
+
+func TestLoadConfig_Presets(t *testing.T) {
+	content := `
+presets:
+  writing:
+    tag: "Writing"
+    target: "1h30m"
+    hooks:
+      on_start: focus-music
+  sprint:
+    pomodoro: "25m/5m x4"
+`
+	path, cleanup := createTestConfigFile(t, content)
+	defer cleanup()
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Dir(filepath.Dir(path)))
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+
+	if names := cfg.PresetNames(); len(names) != 2 || names[0] != "sprint" || names[1] != "writing" {
+		t.Errorf("expected presets [sprint writing], got %v", names)
+	}
+
+	writing, err := cfg.Preset("writing")
+	if err != nil {
+		t.Fatalf("Preset() failed: %v", err)
+	}
+	if writing.Tag != "Writing" || writing.Target != "1h30m" || writing.Hooks["on_start"] != "focus-music" {
+		t.Errorf("unexpected writing preset: %+v", writing)
+	}
+
+	// A preset without a tag is tagged with its name
+	sprint, err := cfg.Preset("sprint")
+	if err != nil {
+		t.Fatalf("Preset() failed: %v", err)
+	}
+	if sprint.Tag != "sprint" || sprint.Pomodoro != "25m/5m x4" {
+		t.Errorf("unexpected sprint preset: %+v", sprint)
+	}
+
+	if _, err := cfg.Preset("missing"); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestLoadConfig_IdleTimeout(t *testing.T) {
+	path, cleanup := createTestConfigFile(t, `idle_timeout: "20m"`)
+	defer cleanup()
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Dir(filepath.Dir(path)))
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() failed: %v", err)
+	}
+	if cfg.ParsedIdleTimeout() != 20*time.Minute {
+		t.Errorf("expected idle timeout to be %v, got %v", 20*time.Minute, cfg.ParsedIdleTimeout())
+	}
+	if cfg.ParsedStaleSessionThreshold() != 8*time.Hour {
+		t.Errorf("expected stale session threshold to keep its default, got %v", cfg.ParsedStaleSessionThreshold())
+	}
+}
+
+func TestLoadConfig_StalePolicy(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`stale_policy: "trim"`, StalePolicyTrim},
+		{`stale_policy: "last_activity"`, StalePolicyLastActivity},
+		{`stale_policy: "bogus"`, StalePolicyKeep},
+		{`stale_session_threshold: "4h"`, StalePolicyKeep},
+	}
+
+	for _, tt := range tests {
+		path, cleanup := createTestConfigFile(t, tt.content)
+		t.Setenv("XDG_CONFIG_HOME", filepath.Dir(filepath.Dir(path)))
+
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig() failed: %v", err)
+		}
+		if cfg.StalePolicy != tt.want {
+			t.Errorf("%s: expected stale policy %q, got %q", tt.content, tt.want, cfg.StalePolicy)
+		}
+		cleanup()
+	}
+}
@@ -6,49 +6,53 @@ import (
 	"time"
 )
 
-func HandleDashboard() {
+// HandleDashboard shows the year of sessions up to the end of dates, or up to
+// today if the range is open-ended. Sessions outside the range are left out.
+func HandleDashboard(statuses StatusFilter, dates DateRange) {
 	reader, err := NewLogReader()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating log reader: %v\n", err)
 		return
 	}
+	reader.SetStatusFilter(statuses)
 
-	// Read all entries. We'll filter them by date later.
-	entries, err := reader.ReadAllEntries()
+	now := time.Now()
+	if !dates.To.IsZero() && dates.To.Before(now) {
+		now = dates.To.Add(-time.Nanosecond)
+	}
+	oneYearAgo := now.AddDate(-1, 0, 0)
+	if dates.From.After(oneYearAgo) {
+		oneYearAgo = dates.From
+	}
+
+	// Only the year shown in the graph is summarised, mostly from the summary index
+	summary, err := reader.Summarize(LogQuery{From: oneYearAgo, To: dates.To})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading log entries: %v\n", err)
 		return
 	}
+	dailyTotals := summary.DailyTotals()
 
-	if len(entries) == 0 {
+	if len(dailyTotals) == 0 {
 		fmt.Println("No sessions logged. Use 'flow start' to begin.")
 		return
 	}
 
-	now := time.Now()
-	oneYearAgo := now.AddDate(-1, 0, 0)
-	dailyTotals := make(map[time.Time]time.Duration)
-
-	for _, entry := range entries {
-		if !entry.EndTime.IsZero() && entry.EndTime.After(oneYearAgo) {
-			day := time.Date(entry.EndTime.Year(), entry.EndTime.Month(), entry.EndTime.Day(), 0, 0, 0, 0, time.UTC)
-			dailyTotals[day] += entry.Duration
-		}
+	title := "Last Year"
+	if !dates.IsZero() {
+		title = dates.String()
 	}
-
-	renderContributionGraph(dailyTotals)
+	renderContributionGraph(dailyTotals, now, title)
 	displayDashboardStats(dailyTotals, now)
 }
 
-func renderContributionGraph(dailyTotals map[time.Time]time.Duration) {
-	now := time.Now()
-
+func renderContributionGraph(dailyTotals map[time.Time]time.Duration, now time.Time, title string) {
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	weekday := int(today.Weekday())
 	lastSunday := today.AddDate(0, 0, -weekday)
 	graphStartDate := lastSunday.AddDate(0, 0, -(51 * 7))
 
-	fmt.Printf("\n%sYour Deep Work History (Last Year)%s\n", Bold, Reset)
+	fmt.Printf("\n%sYour Deep Work History (%s)%s\n", Bold, title, Reset)
 
 	// --- Header Row ---
 	// Create a character buffer for the header to ensure perfect alignment.
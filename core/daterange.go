@@ -0,0 +1,237 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange is the span of time [From, To). A zero From or To leaves that end
+// of the range open.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero reports whether the range is open at both ends
+func (r DateRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// String describes the range by the days it covers, for headings
+func (r DateRange) String() string {
+	last := r.To.Add(-time.Nanosecond)
+	switch {
+	case r.IsZero():
+		return "all time"
+	case r.To.IsZero():
+		return "since " + r.From.Format("Jan 2, 2006")
+	case r.From.IsZero():
+		return "until " + last.Format("Jan 2, 2006")
+	case isToday(r.From, last):
+		return r.From.Format("Jan 2, 2006")
+	case r.From.Year() == last.Year():
+		return r.From.Format("Jan 2") + " - " + last.Format("Jan 2, 2006")
+	}
+	return r.From.Format("Jan 2, 2006") + " - " + last.Format("Jan 2, 2006")
+}
+
+// describeRange phrases a range to follow a noun, as in "Sessions from Jul 1 - Jul 9, 2025"
+func describeRange(r DateRange) string {
+	if r.From.IsZero() || r.To.IsZero() {
+		return r.String()
+	}
+	if isToday(r.From, r.To.Add(-time.Nanosecond)) {
+		return "on " + r.String()
+	}
+	return "from " + r.String()
+}
+
+// dateRangeHelp lists the forms accepted by ParseDateRange
+const dateRangeHelp = "use YYYY-MM-DD, YYYY-MM, YYYY, Q3, a month name, today, yesterday, " +
+	"monday, last week, this month, last quarter, or a relative span like 2w or 3d"
+
+// relativeSpan matches spans counted back from now, like "2w" or "3 days ago"
+var relativeSpan = regexp.MustCompile(`^(\d+)\s*(h|hours?|d|days?|w|weeks?|mo|months?|y|years?)(\s+ago)?$`)
+
+// quarterExpr matches quarters like "q3", "q3 2025" and "2025-q3"
+var quarterExpr = regexp.MustCompile(`^(?:q([1-4])(?:\s+(\d{4}))?|(\d{4})[-\s]?q([1-4]))$`)
+
+// ParseDateRange builds a range from the --since, --until and --range flags.
+// Each expression names a period such as a day, week, month or quarter:
+// since starts at the beginning of its period and until ends at the end of
+// its period, so "--until yesterday" includes yesterday. A range covers its
+// whole period, or runs from the start of one period to the end of another
+// when written as "A..B". Either side of ".." may be left empty.
+func ParseDateRange(since, until, rangeExpr string, now time.Time) (DateRange, error) {
+	var r DateRange
+	if rangeExpr != "" {
+		if since != "" || until != "" {
+			return r, fmt.Errorf("--range can't be combined with --since or --until")
+		}
+		if from, to, ok := strings.Cut(rangeExpr, ".."); ok {
+			since, until = from, to
+		} else {
+			start, end, err := parsePeriod(rangeExpr, now)
+			if err != nil {
+				return r, err
+			}
+			if !start.Before(end) {
+				return r, fmt.Errorf("%q is a point in time, not a range; use --since or --until", rangeExpr)
+			}
+			return DateRange{From: start, To: end}, nil
+		}
+	}
+
+	if strings.TrimSpace(since) != "" {
+		start, _, err := parsePeriod(since, now)
+		if err != nil {
+			return r, err
+		}
+		r.From = start
+	}
+	if strings.TrimSpace(until) != "" {
+		_, end, err := parsePeriod(until, now)
+		if err != nil {
+			return r, err
+		}
+		r.To = end
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return r, fmt.Errorf("the range starts (%s) after it ends (%s)",
+			r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))
+	}
+	return r, nil
+}
+
+// periodRange returns the period named by an expression parsePeriod accepts,
+// for the fixed names used by flags like --today and --week
+func periodRange(expr string, now time.Time) (time.Time, time.Time) {
+	start, end, err := parsePeriod(expr, now)
+	if err != nil {
+		panic(err)
+	}
+	return start, end
+}
+
+// parsePeriod parses a date expression into the period [start, end) it names
+func parsePeriod(value string, now time.Time) (time.Time, time.Time, error) {
+	expr := strings.Join(strings.Fields(strings.ToLower(value)), " ")
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	// Weeks start on Sunday, as in 'flow log --week'
+	week := today.AddDate(0, 0, -int(today.Weekday()))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	quarter := time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, loc)
+	year := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+
+	switch expr {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case "week", "this week":
+		return week, week.AddDate(0, 0, 7), nil
+	case "last week":
+		return week.AddDate(0, 0, -7), week, nil
+	case "month", "this month":
+		return month, month.AddDate(0, 1, 0), nil
+	case "last month":
+		return month.AddDate(0, -1, 0), month, nil
+	case "quarter", "this quarter":
+		return quarter, quarter.AddDate(0, 3, 0), nil
+	case "last quarter":
+		return quarter.AddDate(0, -3, 0), quarter, nil
+	case "year", "this year":
+		return year, year.AddDate(1, 0, 0), nil
+	case "last year":
+		return year.AddDate(-1, 0, 0), year, nil
+	}
+
+	// Weekdays: "monday" is the latest Monday up to today, "last monday" the
+	// latest one before today
+	name, last := strings.CutPrefix(expr, "last ")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name != full && name != full[:3] {
+			continue
+		}
+		back := (int(today.Weekday()) - int(day) + 7) % 7
+		if last && back == 0 {
+			back = 7
+		}
+		start := today.AddDate(0, 0, -back)
+		return start, start.AddDate(0, 0, 1), nil
+	}
+
+	if m := relativeSpan.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		var start time.Time
+		switch m[2][0] {
+		case 'h':
+			start = now.Add(-time.Duration(n) * time.Hour)
+		case 'd':
+			start = now.AddDate(0, 0, -n)
+		case 'w':
+			start = now.AddDate(0, 0, -7*n)
+		case 'm':
+			start = now.AddDate(0, -n, 0)
+		case 'y':
+			start = now.AddDate(-n, 0, 0)
+		}
+		return start, now, nil
+	}
+	if d, err := time.ParseDuration(expr); err == nil && d > 0 {
+		return now.Add(-d), now, nil
+	}
+
+	if m := quarterExpr.FindStringSubmatch(expr); m != nil {
+		q, y := m[1], m[2]
+		if q == "" {
+			q, y = m[4], m[3]
+		}
+		n, _ := strconv.Atoi(q)
+		qYear := now.Year()
+		if y != "" {
+			qYear, _ = strconv.Atoi(y)
+		}
+		start := time.Date(qYear, time.Month(3*n-2), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0), nil
+	}
+
+	// Dates, most specific first
+	if t, err := time.ParseInLocation("2006-01-02", expr, loc); err == nil {
+		return t, t.AddDate(0, 0, 1), nil
+	}
+	if t, err := time.ParseInLocation("2006-01", expr, loc); err == nil {
+		return t, t.AddDate(0, 1, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006", expr, loc); err == nil {
+		return t, t.AddDate(1, 0, 0), nil
+	}
+	for _, layout := range []string{"January 2006", "Jan 2006"} {
+		if t, err := time.ParseInLocation(layout, expr, loc); err == nil {
+			return t, t.AddDate(0, 1, 0), nil
+		}
+	}
+	// A month name alone is the latest such month, this year or last
+	for _, layout := range []string{"January", "Jan"} {
+		if t, err := time.ParseInLocation(layout, expr, loc); err == nil {
+			start := time.Date(now.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+			if start.After(now) {
+				start = start.AddDate(-1, 0, 0)
+			}
+			return start, start.AddDate(0, 1, 0), nil
+		}
+	}
+	// A point in time is a period of its own
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), loc); err == nil {
+			return t, t, nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q (%s)", value, dateRangeHelp)
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2025, 10, 15, 14, 30, 0, 0, time.UTC)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name                   string
+		since, until, rangeArg string
+		want                   DateRange
+	}{
+		{name: "iso day", rangeArg: "2025-07-14", want: DateRange{day(2025, 7, 14), day(2025, 7, 15)}},
+		{name: "iso month", rangeArg: "2025-07", want: DateRange{day(2025, 7, 1), day(2025, 8, 1)}},
+		{name: "year", rangeArg: "2024", want: DateRange{day(2024, 1, 1), day(2025, 1, 1)}},
+		{name: "quarter", rangeArg: "Q3", want: DateRange{day(2025, 7, 1), day(2025, 10, 1)}},
+		{name: "quarter with year", rangeArg: "2024-q4", want: DateRange{day(2024, 10, 1), day(2025, 1, 1)}},
+		{name: "yesterday", rangeArg: "yesterday", want: DateRange{day(2025, 10, 14), day(2025, 10, 15)}},
+		{name: "last week", rangeArg: "last week", want: DateRange{day(2025, 10, 5), day(2025, 10, 12)}},
+		{name: "last quarter", rangeArg: "last quarter", want: DateRange{day(2025, 7, 1), day(2025, 10, 1)}},
+		{name: "month name", rangeArg: "December", want: DateRange{day(2024, 12, 1), day(2025, 1, 1)}},
+		{name: "since last monday", since: "last monday", want: DateRange{From: day(2025, 10, 13)}},
+		{name: "since wednesday is today", since: "wed", want: DateRange{From: day(2025, 10, 15)}},
+		{name: "since last wednesday", since: "last wednesday", want: DateRange{From: day(2025, 10, 8)}},
+		{name: "relative weeks", since: "2w", want: DateRange{From: now.AddDate(0, 0, -14)}},
+		{name: "relative words", since: "3 days ago", want: DateRange{From: now.AddDate(0, 0, -3)}},
+		{name: "until includes the day", until: "yesterday", want: DateRange{To: day(2025, 10, 15)}},
+		{name: "since and until", since: "2025-09-01", until: "2025-09", want: DateRange{day(2025, 9, 1), day(2025, 10, 1)}},
+		{name: "explicit range", rangeArg: "2025-07-01..2025-07-15", want: DateRange{day(2025, 7, 1), day(2025, 7, 16)}},
+		{name: "open-ended range", rangeArg: "Q2..", want: DateRange{From: day(2025, 4, 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateRange(tt.since, tt.until, tt.rangeArg, now)
+			if err != nil {
+				t.Fatalf("ParseDateRange() error = %v", err)
+			}
+			if !got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Errorf("ParseDateRange() = %v - %v, want %v - %v", got.From, got.To, tt.want.From, tt.want.To)
+			}
+		})
+	}
+
+	for _, invalid := range [][3]string{
+		{"", "", "someday"},
+		{"2025-09-01", "", "Q3"},
+		{"2025-09-02", "2025-09-01", ""},
+		{"", "", "2025-09-01 10:00"},
+	} {
+		if _, err := ParseDateRange(invalid[0], invalid[1], invalid[2], now); err == nil {
+			t.Errorf("ParseDateRange(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestDateRangeString(t *testing.T) {
+	from := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		r    DateRange
+		want string
+	}{
+		{DateRange{From: from, To: from.AddDate(0, 0, 1)}, "Jul 1, 2025"},
+		{DateRange{From: from, To: from.AddDate(0, 3, 0)}, "Jul 1 - Sep 30, 2025"},
+		{DateRange{From: from, To: from.AddDate(1, 0, 0)}, "Jul 1, 2025 - Jun 30, 2026"},
+		{DateRange{From: from}, "since Jul 1, 2025"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestReadRange(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FLOW_LOG_PATH", "")
+
+	for _, end := range []time.Time{
+		time.Date(2025, 6, 30, 12, 0, 0, 0, time.Local),
+		time.Date(2025, 7, 10, 12, 0, 0, 0, time.Local),
+		time.Date(2025, 9, 30, 12, 0, 0, 0, time.Local),
+		time.Date(2025, 10, 1, 12, 0, 0, 0, time.Local),
+	} {
+		if err := LogSession(LogEntry{Tag: end.Format("Jan 2"), StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reader, err := NewLogReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q3 := DateRange{From: time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local), To: time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local)}
+	entries, err := reader.ReadRange(q3, 0)
+	if err != nil {
+		t.Fatalf("ReadRange() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Tag != "Sep 30" || entries[1].Tag != "Jul 10" {
+		t.Errorf("ReadRange(Q3) = %+v, want the two sessions in Q3", entries)
+	}
+}
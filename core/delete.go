@@ -2,22 +2,54 @@ package core
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 )
 
-// DeleteLogEntry removes a specific log entry from the log files.
+// DeleteLogEntry removes a specific log entry from the log.
+// Entries are matched by ID; entries without an ID fall back to StartTime and Tag.
 func DeleteLogEntry(entryToDelete LogEntry) error {
-	logPath, err := GetLogPath(entryToDelete.EndTime)
+	return withStore(func(store Store) error {
+		return store.Delete(entryToDelete)
+	})
+}
+
+// isSameEntry reports whether a stored entry is the one identified by target
+func isSameEntry(entry, target LogEntry) bool {
+	if target.ID != "" {
+		return entry.ID == target.ID
+	}
+	return entry.StartTime.Equal(target.StartTime) && entry.Tag == target.Tag
+}
+
+// rewriteLogFile passes every entry in a log file through fn and atomically
+// replaces the file with the result. fn returns the line to write and whether
+// to keep the entry at all; returning the line unchanged keeps it as-is.
+// Malformed lines are kept as they are. The file is only replaced if fn
+// changed or dropped at least one entry, which is reported as changed.
+func rewriteLogFile(logPath string, fn func(entry LogEntry, line string) (string, bool)) (changed bool, err error) {
+	return rewriteLines(logPath, func(line string) (string, bool) {
+		entry, err := parseLogLine(line)
+		if err != nil {
+			return line, true
+		}
+		return fn(entry, line)
+	})
+}
+
+// rewriteLines is rewriteLogFile for raw lines: fn sees every line, including
+// ones that aren't valid entries.
+func rewriteLines(logPath string, fn func(line string) (string, bool)) (changed bool, err error) {
+	unlock, err := lockLogs()
 	if err != nil {
-		return err
+		return false, err
 	}
+	defer unlock()
 
 	file, err := os.Open(logPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
@@ -28,10 +60,11 @@ func DeleteLogEntry(entryToDelete LogEntry) error {
 
 	tempFile, err := os.CreateTemp(filepath.Dir(logPath), "temp_log_")
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer func() {
-		if removeErr := os.Remove(tempFile.Name()); removeErr != nil {
+		// The temp file is gone once it has been renamed over the log file
+		if removeErr := os.Remove(tempFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
 			// Log the error but don't return it as it's in a defer
 			fmt.Fprintf(os.Stderr, "Warning: failed to remove temp file: %v\n", removeErr)
 		}
@@ -39,25 +72,22 @@ func DeleteLogEntry(entryToDelete LogEntry) error {
 
 	scanner := bufio.NewScanner(file)
 	writer := bufio.NewWriter(tempFile)
-	found := false
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		var entry LogEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			// Skip malformed lines
+		newLine, keep := fn(line)
+		if !keep {
+			changed = true
 			continue
 		}
-
-		if entry.StartTime.Equal(entryToDelete.StartTime) && entry.Tag == entryToDelete.Tag {
-			found = true
-		} else {
-			if _, writeErr := fmt.Fprintln(writer, line); writeErr != nil {
-				if closeErr := tempFile.Close(); closeErr != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to close temp file: %v\n", closeErr)
-				}
-				return writeErr
+		if newLine != line {
+			changed = true
+		}
+		if _, writeErr := fmt.Fprintln(writer, newLine); writeErr != nil {
+			if closeErr := tempFile.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close temp file: %v\n", closeErr)
 			}
+			return false, writeErr
 		}
 	}
 
@@ -65,23 +95,22 @@ func DeleteLogEntry(entryToDelete LogEntry) error {
 		if closeErr := tempFile.Close(); closeErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to close temp file: %v\n", closeErr)
 		}
-		return err
+		return false, err
 	}
 
 	if err := writer.Flush(); err != nil {
 		if closeErr := tempFile.Close(); closeErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to close temp file: %v\n", closeErr)
 		}
-		return err
+		return false, err
 	}
 
 	if closeErr := tempFile.Close(); closeErr != nil {
-		return closeErr
+		return false, closeErr
 	}
 
-	if found {
-		return os.Rename(tempFile.Name(), logPath)
+	if !changed {
+		return false, nil
 	}
-
-	return fmt.Errorf("log entry not found")
+	return true, os.Rename(tempFile.Name(), logPath)
 }
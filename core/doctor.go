@@ -0,0 +1,446 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of problems found by CheckData
+const (
+	ProblemConfig    = "config"
+	ProblemSession   = "session"
+	ProblemMalformed = "malformed"
+	ProblemMisfiled  = "misfiled"
+	ProblemDuplicate = "duplicate"
+	ProblemDuration  = "duration"
+	ProblemMismatch  = "mismatch"
+	ProblemOverlap   = "overlap"
+)
+
+// durationTolerance absorbs rounding in durations written by older versions
+const durationTolerance = time.Second
+
+// Problem is a single integrity problem in the stored data
+type Problem struct {
+	Kind    string
+	Where   string // file:line for problems in a file, otherwise the entry's short ID
+	Message string
+	Fixable bool
+	Fixed   bool
+}
+
+// DoctorReport summarises a data integrity check
+type DoctorReport struct {
+	Problems   []Problem
+	Files      int
+	Entries    int
+	Backup     string
+	Quarantine string
+}
+
+// Fixable returns the number of problems that can be repaired automatically
+func (r DoctorReport) Fixable() int {
+	count := 0
+	for _, p := range r.Problems {
+		if p.Fixable {
+			count++
+		}
+	}
+	return count
+}
+
+// Unresolved returns the number of problems left after the check
+func (r DoctorReport) Unresolved() int {
+	count := 0
+	for _, p := range r.Problems {
+		if !p.Fixed {
+			count++
+		}
+	}
+	return count
+}
+
+// doctor holds the state of a single check
+type doctor struct {
+	report     DoctorReport
+	fix        bool
+	backend    string
+	device     string // this device in sync mode, which can't fix other devices' files
+	quarantine string
+	seen       map[string]string // entry ID to its first copy, as JSON
+	moves      map[string][]LogEntry
+	foreign    map[string]bool // IDs of entries in other devices' files
+}
+
+func (d *doctor) add(p Problem) {
+	p.Fixed = d.fix && p.Fixable
+	d.report.Problems = append(d.report.Problems, p)
+}
+
+// CheckData checks the configuration, the active session and the session log
+// for problems. With fix, repairs that can't lose data are applied: malformed
+// lines are moved to the quarantine directory, misfiled entries are moved to
+// the file for the month they ended in, exact duplicates are removed and
+// durations that don't match the entry's times are recomputed. The data
+// directory is backed up before anything is changed.
+func CheckData(fix bool) (DoctorReport, error) {
+	report, err := checkData(false)
+	if err != nil || !fix || report.Fixable() == 0 {
+		return report, err
+	}
+
+	backup, err := BackupDataDir("doctor")
+	if err != nil {
+		return report, fmt.Errorf("failed to back up data before repairing it: %w", err)
+	}
+	report, err = checkData(true)
+	report.Backup = backup
+	return report, err
+}
+
+func checkData(fix bool) (DoctorReport, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return DoctorReport{}, err
+	}
+	d := &doctor{
+		fix:        fix,
+		backend:    StorageJSONL,
+		quarantine: filepath.Join(dataDir, "quarantine"),
+		seen:       make(map[string]string),
+		moves:      make(map[string][]LogEntry),
+		foreign:    make(map[string]bool),
+	}
+
+	// Without a readable config, the log is checked in the default backend
+	config, err := LoadConfig()
+	if err != nil {
+		d.add(Problem{Kind: ProblemConfig, Where: "config.yml", Message: err.Error()})
+	} else if config.Storage != "" {
+		d.backend = config.Storage
+	}
+	d.device = syncDevice()
+
+	store, err := OpenStoreBackend(d.backend)
+	if err != nil {
+		return d.report, err
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	if err := d.checkSession(store); err != nil {
+		return d.report, err
+	}
+	if err := d.checkLogFiles(); err != nil {
+		return d.report, err
+	}
+	if err := d.checkEntries(store); err != nil {
+		return d.report, err
+	}
+	if d.quarantineUsed() {
+		d.report.Quarantine = d.quarantine
+	}
+	return d.report, nil
+}
+
+// quarantineUsed reports whether anything was moved to the quarantine directory
+func (d *doctor) quarantineUsed() bool {
+	for _, p := range d.report.Problems {
+		if p.Fixed && (p.Kind == ProblemMalformed || p.Kind == ProblemSession) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSession reports an active session that can't be read. A session file
+// that isn't valid JSON is quarantined.
+func (d *doctor) checkSession(store Store) error {
+	_, err := store.GetSession()
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	problem := Problem{Kind: ProblemSession, Where: "active session", Message: fmt.Sprintf("can't be read: %v", err)}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	problem.Fixable = d.backend == StorageJSONL && (errors.As(err, &syntaxErr) || errors.As(err, &typeErr))
+	d.add(problem)
+	if !d.fix || !problem.Fixable {
+		return nil
+	}
+
+	sessionPath, err := GetSessionPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		return err
+	}
+	if err := quarantineLines(d.quarantine, filepath.Base(sessionPath), []string{string(data)}); err != nil {
+		return err
+	}
+	return os.Remove(sessionPath)
+}
+
+// checkLogFiles checks every line of the session and break logs, oldest
+// first. Archived months are checked too, but only reported.
+func (d *doctor) checkLogFiles() error {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	if d.backend == StorageJSONL {
+		sessions, err := deviceFiles(filepath.Join(logDir, "*_sessions.jsonl"))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, sessions...)
+		archives, err := filepath.Glob(filepath.Join(logDir, "*_sessions*.jsonl"+archiveSuffix))
+		if err != nil {
+			return err
+		}
+		for _, path := range archives {
+			if _, _, ok := parseLogFileName(path); ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	breaks, err := deviceFiles(filepath.Join(logDir, "*_breaks.jsonl"))
+	if err != nil {
+		return err
+	}
+	paths = append(paths, breaks...)
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pathFor := GetLogPath
+		if strings.Contains(filepath.Base(path), "_breaks.") {
+			pathFor = GetBreakLogPath
+		}
+		if device := fileDevice(path); device != "" {
+			// A device's entries belong in that device's file for their month
+			shared := pathFor
+			pathFor = func(date time.Time) (string, error) {
+				logPath, err := shared(date)
+				return deviceLogPath(logPath, device), err
+			}
+		}
+		if err := d.checkLogFile(path, pathFor); err != nil {
+			return err
+		}
+		d.report.Files++
+	}
+
+	// Misfiled entries are moved once every file has been checked, so they
+	// aren't mistaken for duplicates of themselves in their new file
+	targets := make([]string, 0, len(d.moves))
+	for target := range d.moves {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		for _, entry := range d.moves[target] {
+			if err := appendLogEntry(target, entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkLogFile checks the lines of one log file. pathFor returns the file an
+// entry belongs in. Problems in an archive or another device's file are only
+// reported.
+func (d *doctor) checkLogFile(path string, pathFor func(time.Time) (string, error)) error {
+	name := filepath.Base(path)
+	own := !otherDevices(path, d.device) && !isArchive(path)
+	var quarantined []string
+	lineNumber := 0
+
+	check := func(line string) (string, bool) {
+		lineNumber++
+		if strings.TrimSpace(line) == "" {
+			return line, true
+		}
+		where := fmt.Sprintf("%s:%d", name, lineNumber)
+
+		entry, err := parseLogLine(line)
+		if err != nil {
+			d.add(Problem{Kind: ProblemMalformed, Where: where, Message: "not a valid log entry", Fixable: own})
+			if d.fix && own {
+				quarantined = append(quarantined, line)
+				return "", false
+			}
+			return line, true
+		}
+		d.report.Entries++
+		if !own {
+			d.foreign[entry.ID] = true
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return line, true
+		}
+		if first, ok := d.seen[entry.ID]; ok {
+			if first == string(data) {
+				d.add(Problem{Kind: ProblemDuplicate, Where: where, Message: fmt.Sprintf("%q is an exact copy of an earlier entry", entry.Tag), Fixable: own})
+				if d.fix && own {
+					return "", false
+				}
+			} else {
+				d.add(Problem{Kind: ProblemDuplicate, Where: where, Message: fmt.Sprintf("%q shares its ID %s with a different entry", entry.Tag, ShortID(entry.ID))})
+			}
+			return line, true
+		}
+		d.seen[entry.ID] = string(data)
+
+		target, err := pathFor(entry.EndTime)
+		if err == nil && filepath.Base(target) != strings.TrimSuffix(name, archiveSuffix) {
+			d.add(Problem{Kind: ProblemMisfiled, Where: where, Message: fmt.Sprintf("%q ended in %s but is filed in %s", entry.Tag, entry.EndTime.Format("January 2006"), name), Fixable: own})
+			if d.fix && own {
+				d.moves[target] = append(d.moves[target], entry)
+				return "", false
+			}
+		}
+		return line, true
+	}
+
+	if !d.fix || !own {
+		return forEachLine(path, func(line string) { check(line) })
+	}
+	if _, err := rewriteLines(path, check); err != nil {
+		return err
+	}
+	return quarantineLines(d.quarantine, name, quarantined)
+}
+
+// checkEntries checks the logged sessions' durations and looks for sessions
+// that overlap
+func (d *doctor) checkEntries(store Store) error {
+	entries, err := collect(store.Entries(LogQuery{Statuses: allStatuses}))
+	if err != nil {
+		return err
+	}
+	if d.backend != StorageJSONL {
+		// Only the break logs were counted by the file checks
+		d.report.Entries += len(entries)
+	}
+
+	for _, entry := range entries {
+		where := ShortID(entry.ID)
+		span := entry.EndTime.Sub(entry.StartTime)
+		if span <= 0 || entry.Duration <= 0 {
+			d.add(Problem{Kind: ProblemDuration, Where: where, Message: fmt.Sprintf("%q on %s has no duration", entry.Tag, entry.StartTime.Format("Jan 2, 2006 15:04"))})
+			continue
+		}
+
+		expected := span - entry.TotalPaused
+		if diff := entry.Duration - expected; expected > 0 && entry.TotalPaused >= 0 && (diff > durationTolerance || diff < -durationTolerance) {
+			d.add(Problem{Kind: ProblemMismatch, Where: where, Fixable: !d.foreign[entry.ID], Message: fmt.Sprintf("%q on %s records %s of focus time, but its times and pauses add up to %s",
+				entry.Tag, entry.StartTime.Format("Jan 2, 2006 15:04"), FormatDuration(entry.Duration), FormatDuration(expected))})
+			if d.fix && !d.foreign[entry.ID] {
+				fixed := entry
+				fixed.Duration = expected
+				if err := store.Update(entry, fixed); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartTime.Before(entries[j].StartTime)
+	})
+	// Duplicates would report the same overlap more than once
+	reported := make(map[[2]string]bool)
+	for i, entry := range entries {
+		for _, later := range entries[i+1:] {
+			if !later.StartTime.Before(entry.EndTime) {
+				break
+			}
+			pair := [2]string{entry.ID, later.ID}
+			if later.ID == entry.ID || reported[pair] || overlapIsPaused(entry, later) {
+				continue
+			}
+			reported[pair] = true
+			d.add(Problem{Kind: ProblemOverlap, Where: ShortID(later.ID), Message: fmt.Sprintf("%q (%s) overlaps %q (%s)",
+				later.Tag, formatSpan(later), entry.Tag, formatSpan(entry))})
+		}
+	}
+	return nil
+}
+
+// overlapIsPaused reports whether the time two entries share falls within a
+// pause of either, as when a session was put on hold for an interruption
+func overlapIsPaused(a, b LogEntry) bool {
+	start, end := b.StartTime, a.EndTime
+	if a.StartTime.After(start) {
+		start = a.StartTime
+	}
+	if b.EndTime.Before(end) {
+		end = b.EndTime
+	}
+	for _, pause := range append(append([]Pause{}, a.Pauses...), b.Pauses...) {
+		if !pause.Start.After(start) && !pause.End.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSpan shows when an entry ran
+func formatSpan(entry LogEntry) string {
+	return entry.StartTime.Format("Jan 2 15:04") + "-" + entry.EndTime.Format("15:04")
+}
+
+// forEachLine calls fn with every line of a file, decompressing archives
+func forEachLine(path string, fn func(line string)) error {
+	file, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// quarantineLines appends lines removed from a log file to a file of the same
+// name in the quarantine directory, so they can be inspected and restored
+func quarantineLines(dir, name string, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(file, line); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
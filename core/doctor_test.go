@@ -0,0 +1,119 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckData(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataDir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FLOW_LOG_PATH", "")
+	t.Setenv("FLOW_SESSION_PATH", "")
+
+	logDir := filepath.Join(dataDir, "flow", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AA","tag":"Original","start_time":"2025-01-02T09:00:00Z","end_time":"2025-01-02T12:00:00Z","duration":7200000000000,"total_paused":3600000000000,"pauses":[{"start":"2025-01-02T10:00:00Z","end":"2025-01-02T11:00:00Z","reason":"Incident"}],"status":"completed"}`,
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AB","tag":"Incident","start_time":"2025-01-02T10:00:00Z","end_time":"2025-01-02T11:00:00Z","duration":3600000000000,"status":"completed"}`,
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AB","tag":"Incident","start_time":"2025-01-02T10:00:00Z","end_time":"2025-01-02T11:00:00Z","duration":3600000000000,"status":"completed"}`,
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AC","tag":"Wrong duration","start_time":"2025-01-03T09:00:00Z","end_time":"2025-01-03T10:00:00Z","duration":60000000000,"status":"completed"}`,
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AD","tag":"Overlapping","start_time":"2025-01-03T09:30:00Z","end_time":"2025-01-03T10:30:00Z","duration":3600000000000,"status":"completed"}`,
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AE","tag":"February","start_time":"2025-02-03T09:00:00Z","end_time":"2025-02-03T10:00:00Z","duration":3600000000000,"status":"completed"}`,
+		`not json`,
+	}
+	janPath := filepath.Join(logDir, "202501_sessions.jsonl")
+	if err := os.WriteFile(janPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := func(report DoctorReport) string {
+		var result []string
+		for _, p := range report.Problems {
+			result = append(result, p.Kind)
+		}
+		return strings.Join(result, ",")
+	}
+
+	report, err := CheckData(false)
+	if err != nil {
+		t.Fatalf("CheckData() error = %v", err)
+	}
+	// The interruption falls within the original session's pause, so only
+	// the second pair of sessions overlaps
+	if got, want := kinds(report), "duplicate,misfiled,malformed,mismatch,overlap"; got != want {
+		t.Fatalf("CheckData() problems = %s, want %s", got, want)
+	}
+	if report.Fixable() != 4 || report.Unresolved() != 5 || report.Backup != "" {
+		t.Errorf("CheckData() = %+v", report)
+	}
+
+	report, err = CheckData(true)
+	if err != nil {
+		t.Fatalf("CheckData(fix) error = %v", err)
+	}
+	if report.Unresolved() != 1 || report.Backup == "" || report.Quarantine == "" {
+		t.Errorf("CheckData(fix) = %+v, want only the overlap left", report)
+	}
+
+	quarantined, err := os.ReadFile(filepath.Join(report.Quarantine, "202501_sessions.jsonl"))
+	if err != nil || strings.TrimSpace(string(quarantined)) != "not json" {
+		t.Errorf("Quarantined lines = %q, %v", quarantined, err)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, "202502_sessions.jsonl")); err != nil {
+		t.Errorf("Expected the misfiled entry to move to February: %v", err)
+	}
+	entry, err := FindLogEntry("01JGK3WEM0A8FDCAMJT1V2F6AC")
+	if err != nil || entry.Duration != time.Hour {
+		t.Errorf("Duration after fixing = %v, %v; want 1h", entry.Duration, err)
+	}
+
+	report, err = CheckData(false)
+	if err != nil || kinds(report) != "overlap" {
+		t.Errorf("CheckData() after fixing = %s, %v; want only the overlap", kinds(report), err)
+	}
+}
+
+func TestCheckDataArchives(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataDir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FLOW_LOG_PATH", "")
+	t.Setenv("FLOW_SESSION_PATH", "")
+
+	logDir := filepath.Join(dataDir, "flow", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		`{"id":"01JGK3WEM0A8FDCAMJT1V2F6AA","tag":"Archived","start_time":"2024-12-02T09:00:00Z","end_time":"2024-12-02T10:00:00Z","duration":3600000000000,"status":"completed"}`,
+		`not json`,
+	}
+	path := filepath.Join(logDir, "202412_sessions.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archived, err := compressLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Problems in an archive are reported but left alone
+	report, err := CheckData(true)
+	if err != nil || report.Entries != 1 || len(report.Problems) != 1 || report.Problems[0].Kind != ProblemMalformed || report.Problems[0].Fixed {
+		t.Fatalf("CheckData(fix) with an archive = %+v, %v", report, err)
+	}
+	if after, _ := os.ReadFile(archived); string(after) != string(before) {
+		t.Error("Expected the archive to be left unchanged")
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"fmt"
+)
+
+// EditLogEntry replaces a logged entry with an updated version. The duration is
+// recomputed from the start, end and paused times. The stored entry is returned.
+func EditLogEntry(original, updated LogEntry) (LogEntry, error) {
+	updated.ID = original.ID
+	if err := validateEntryTimes(&updated); err != nil {
+		return updated, err
+	}
+	updated.Pauses = PausesBetween(updated.Pauses, updated.StartTime, updated.EndTime)
+
+	err := withStore(func(store Store) error {
+		return store.Update(original, updated)
+	})
+	return updated, err
+}
+
+// validateEntryTimes checks that an entry's times are consistent and
+// recomputes its duration.
+func validateEntryTimes(entry *LogEntry) error {
+	if entry.StartTime.IsZero() || entry.EndTime.IsZero() {
+		return fmt.Errorf("start and end time are required")
+	}
+	if !entry.EndTime.After(entry.StartTime) {
+		return fmt.Errorf("end time (%s) must be after start time (%s)",
+			entry.EndTime.Format("2006-01-02 15:04"), entry.StartTime.Format("2006-01-02 15:04"))
+	}
+	if entry.TotalPaused < 0 {
+		return fmt.Errorf("paused time cannot be negative")
+	}
+
+	duration := entry.EndTime.Sub(entry.StartTime) - entry.TotalPaused
+	if duration < 0 {
+		return fmt.Errorf("paused time (%s) is longer than the session (%s)",
+			FormatDuration(entry.TotalPaused), FormatDuration(entry.EndTime.Sub(entry.StartTime)))
+	}
+	entry.Duration = duration
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEditLogEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	for _, entry := range []LogEntry{
+		{Tag: "first", StartTime: start, EndTime: start.Add(time.Hour), Duration: time.Hour},
+		{Tag: "second", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), Duration: time.Hour},
+	} {
+		if err := LogSession(entry); err != nil {
+			t.Fatalf("LogSession() error = %v", err)
+		}
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	original := entries[1] // "first", entries are newest first
+
+	t.Run("same month", func(t *testing.T) {
+		updated := original
+		updated.Tag = "renamed"
+		updated.EndTime = start.Add(90 * time.Minute)
+		updated.TotalPaused = 10 * time.Minute
+
+		stored, err := EditLogEntry(original, updated)
+		if err != nil {
+			t.Fatalf("EditLogEntry() error = %v", err)
+		}
+		if stored.Duration != 80*time.Minute {
+			t.Errorf("Expected recomputed duration 80m, got %v", stored.Duration)
+		}
+
+		found, err := FindLogEntry(original.ID)
+		if err != nil {
+			t.Fatalf("FindLogEntry() error = %v", err)
+		}
+		if found.Tag != "renamed" || found.Duration != 80*time.Minute {
+			t.Errorf("Stored entry not updated: %+v", found)
+		}
+		original = found
+	})
+
+	t.Run("move to another month", func(t *testing.T) {
+		updated := original
+		updated.StartTime = time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+		updated.EndTime = time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+		updated.TotalPaused = 0
+
+		if _, err := EditLogEntry(original, updated); err != nil {
+			t.Fatalf("EditLogEntry() error = %v", err)
+		}
+
+		may, _ := reader.ReadMonthEntries(start, 0)
+		if len(may) != 1 || may[0].Tag != "second" {
+			t.Errorf("Expected only 'second' to remain in May, got %+v", may)
+		}
+		june, _ := reader.ReadMonthEntries(updated.EndTime, 0)
+		if len(june) != 1 || june[0].ID != original.ID {
+			t.Errorf("Expected the edited entry in June with its ID kept, got %+v", june)
+		}
+	})
+
+	t.Run("invalid times", func(t *testing.T) {
+		found, _ := FindLogEntry(original.ID)
+
+		endBeforeStart := found
+		endBeforeStart.EndTime = found.StartTime.Add(-time.Minute)
+		if _, err := EditLogEntry(found, endBeforeStart); err == nil {
+			t.Error("Expected an error when the end is before the start")
+		}
+
+		tooMuchPause := found
+		tooMuchPause.TotalPaused = 2 * time.Hour
+		if _, err := EditLogEntry(found, tooMuchPause); err == nil {
+			t.Error("Expected an error when paused time exceeds the session")
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		found, _ := FindLogEntry(original.ID)
+		if _, err := EditLogEntry(found, found); err != nil {
+			t.Errorf("EditLogEntry() without changes error = %v", err)
+		}
+	})
+
+	t.Run("missing entry", func(t *testing.T) {
+		ghost := LogEntry{ID: NewID(start), StartTime: start, EndTime: start.Add(time.Hour)}
+		if _, err := EditLogEntry(ghost, ghost); err == nil {
+			t.Error("Expected an error when editing an entry that does not exist")
+		}
+	})
+
+	// No temp files are left behind
+	logDir, _ := GetLogDir()
+	files, _ := os.ReadDir(logDir)
+	for _, f := range files {
+		if len(f.Name()) > 9 && f.Name()[:9] == "temp_log_" {
+			t.Errorf("Leftover temp file %s", f.Name())
+		}
+	}
+}
@@ -15,6 +15,10 @@ func HandleExport() {
 	// --- Argument Parsing ---
 	var filterToday, filterWeek, filterMonth, showAll bool
 	var targetMonth *time.Time
+	var includeStatus, excludeStatus string
+	var since, until, rangeExpr string
+	var projects ProjectFilter
+	rollup := false
 	format := "csv"  // Default format
 	outputFile := "" // Default to stdout
 
@@ -45,6 +49,57 @@ func HandleExport() {
 				outputFile = args[i+1]
 				i++
 			}
+		case strings.HasPrefix(arg, "--status="):
+			includeStatus = strings.TrimPrefix(arg, "--status=")
+		case arg == "--status":
+			if i+1 < len(args) {
+				includeStatus = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--exclude-status="):
+			excludeStatus = strings.TrimPrefix(arg, "--exclude-status=")
+		case arg == "--exclude-status":
+			if i+1 < len(args) {
+				excludeStatus = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--project="):
+			projects.Project = NormalizeProject(strings.TrimPrefix(arg, "--project="))
+		case arg == "--project":
+			if i+1 < len(args) {
+				projects.Project = NormalizeProject(args[i+1])
+				i++
+			}
+		case strings.HasPrefix(arg, "--label="):
+			projects.Labels = append(projects.Labels, strings.Split(strings.TrimPrefix(arg, "--label="), ",")...)
+		case arg == "--label":
+			if i+1 < len(args) {
+				projects.Labels = append(projects.Labels, strings.Split(args[i+1], ",")...)
+				i++
+			}
+		case strings.HasPrefix(arg, "--since="):
+			since = strings.TrimPrefix(arg, "--since=")
+		case arg == "--since":
+			if i+1 < len(args) {
+				since = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--until="):
+			until = strings.TrimPrefix(arg, "--until=")
+		case arg == "--until":
+			if i+1 < len(args) {
+				until = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--range="):
+			rangeExpr = strings.TrimPrefix(arg, "--range=")
+		case arg == "--range":
+			if i+1 < len(args) {
+				rangeExpr = args[i+1]
+				i++
+			}
+		case arg == "--rollup":
+			rollup = true
 		default:
 			if t, err := time.Parse("2006-01", arg); err == nil {
 				targetMonth = &t
@@ -53,29 +108,47 @@ func HandleExport() {
 	}
 
 	// --- Data Fetching ---
+	statuses, err := ParseStatusFilter(includeStatus, excludeStatus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	dates, err := ParseDateRange(since, until, rangeExpr, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
 	reader, err := NewLogReader()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating log reader: %v\n", err)
 		return
 	}
+	reader.SetStatusFilter(statuses)
+	reader.SetProjectFilter(projects)
 
-	var entries []LogEntry
-	if showAll {
-		entries, err = reader.ReadAllEntries()
-	} else if targetMonth != nil {
-		entries, err = reader.ReadMonthEntries(*targetMonth, 0)
-	} else if filterToday || filterWeek || filterMonth {
-		// ReadRecentEntries can handle these filters. Limit 0 means no limit.
-		entries, err = reader.ReadRecentEntries(0, filterToday, filterWeek)
-		if filterMonth && !filterToday && !filterWeek { // handle --month separately
-			now := time.Now()
-			entries, err = reader.ReadMonthEntries(now, 0)
-		}
-	} else {
-		// Default: read recent entries (same as `flow log`)
-		entries, err = reader.ReadRecentEntries(defaultMaxEntries, false, false)
+	// Periods are exported in full; without one, only the recent entries are (same as `flow log`)
+	var q LogQuery
+	now := time.Now()
+	switch {
+	case !dates.IsZero():
+		q.From, q.To = dates.From, dates.To
+	case showAll:
+	case targetMonth != nil:
+		q.From, q.To = periodRange(targetMonth.Format("2006-01"), now)
+	case filterToday:
+		q.From, q.To = periodRange("today", now)
+	case filterWeek:
+		q.From, q.To = periodRange("week", now)
+	case filterMonth:
+		q.From, q.To = periodRange("month", now)
+	default:
+		q.Limit = defaultMaxEntries
 	}
 
+	entries, err := reader.Query(q)
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading log entries: %v\n", err)
 		return
@@ -103,10 +176,14 @@ func HandleExport() {
 		fmt.Fprintf(os.Stderr, "Exporting %d entries to %s...\n", len(entries), outputFile)
 	}
 
-	switch format {
-	case "csv":
+	switch {
+	case rollup && format == "csv":
+		exportRollupCSV(writer, CalculateProjectRollup(entries))
+	case rollup && format == "json":
+		exportRollupJSON(writer, CalculateProjectRollup(entries))
+	case format == "csv":
 		exportCSV(writer, entries)
-	case "json":
+	case format == "json":
 		exportJSON(writer, entries)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown format: %s. Supported formats are csv, json.\n", format)
@@ -121,6 +198,8 @@ func exportCSV(writer io.Writer, entries []LogEntry) {
 	headers := []string{
 		"tag", "start_time", "end_time", "duration_seconds",
 		"total_paused_seconds", "duration_formatted", "total_paused_formatted",
+		"interruptions", "status", "project", "labels",
+		"repo", "branch", "commits",
 	}
 	if err := csvWriter.Write(headers); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing CSV header: %v\n", err)
@@ -129,6 +208,10 @@ func exportCSV(writer io.Writer, entries []LogEntry) {
 
 	// Write rows
 	for _, entry := range entries {
+		branch := ""
+		if entry.Git != nil {
+			branch = entry.Git.Branch
+		}
 		row := []string{
 			entry.Tag,
 			entry.StartTime.Format(time.RFC3339),
@@ -137,6 +220,13 @@ func exportCSV(writer io.Writer, entries []LogEntry) {
 			strconv.FormatInt(int64(entry.TotalPaused.Seconds()), 10),
 			FormatDuration(entry.Duration),
 			FormatDuration(entry.TotalPaused),
+			strconv.Itoa(len(entry.Pauses)),
+			entry.Status,
+			entry.Project,
+			strings.Join(entry.Labels, ";"),
+			entry.Git.Repo(),
+			branch,
+			strconv.Itoa(len(entry.Commits)),
 		}
 		if err := csvWriter.Write(row); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing CSV row: %v\n", err)
@@ -145,6 +235,58 @@ func exportCSV(writer io.Writer, entries []LogEntry) {
 	}
 }
 
+// exportRollupCSV writes the time per project, one row per level of the hierarchy
+func exportRollupCSV(writer io.Writer, projects []ProjectStat) {
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	headers := []string{"project", "depth", "sessions", "duration_seconds", "duration_formatted"}
+	if err := csvWriter.Write(headers); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV header: %v\n", err)
+		return
+	}
+
+	for _, project := range projects {
+		row := []string{
+			project.Project,
+			strconv.Itoa(project.Depth),
+			strconv.Itoa(project.Count),
+			strconv.FormatInt(int64(project.Duration.Seconds()), 10),
+			FormatDuration(project.Duration),
+		}
+		if err := csvWriter.Write(row); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV row: %v\n", err)
+			continue
+		}
+	}
+}
+
+// projectRollupRow is the JSON form of a ProjectStat
+type projectRollupRow struct {
+	Project         string `json:"project"`
+	Depth           int    `json:"depth"`
+	Sessions        int    `json:"sessions"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
+
+// exportRollupJSON writes the time per project as a JSON array
+func exportRollupJSON(writer io.Writer, projects []ProjectStat) {
+	rows := []projectRollupRow{}
+	for _, project := range projects {
+		rows = append(rows, projectRollupRow{
+			Project:         project.Project,
+			Depth:           project.Depth,
+			Sessions:        project.Count,
+			DurationSeconds: int64(project.Duration.Seconds()),
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(rows); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
+
 func exportJSON(writer io.Writer, entries []LogEntry) {
 	if entries == nil {
 		if _, err := writer.Write([]byte("[]\n")); err != nil {
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GitContext records where a session was started and, inside a git
+// repository, which repository and branch it was about
+type GitContext struct {
+	Dir    string `json:"dir"`
+	Root   string `json:"repo_root,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// Repo returns the name of the repository, the last element of its root
+func (g *GitContext) Repo() string {
+	if g == nil || g.Root == "" {
+		return ""
+	}
+	return filepath.Base(g.Root)
+}
+
+// Commit is a commit made during a session
+type Commit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// runGit runs a git command in dir and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// DetectGitContext describes the working directory dir. Outside a git
+// repository, or without git installed, only the directory is recorded.
+func DetectGitContext(dir string) *GitContext {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	ctx := &GitContext{Dir: dir}
+
+	root, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
+		return ctx
+	}
+	ctx.Root = filepath.FromSlash(root)
+
+	if branch, err := runGit(dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		ctx.Branch = branch
+	}
+
+	// Prefer the branch's upstream remote, then origin, then whatever there is
+	if remote, err := runGit(dir, "config", "branch."+ctx.Branch+".remote"); err == nil && remote != "" && remote != "." {
+		ctx.Remote = remote
+	} else if remotes, err := runGit(dir, "remote"); err == nil && remotes != "" {
+		names := strings.Fields(remotes)
+		ctx.Remote = names[0]
+		if slices.Contains(names, "origin") {
+			ctx.Remote = "origin"
+		}
+	}
+	return ctx
+}
+
+// CurrentGitContext describes the current working directory, or returns nil
+// if it cannot be determined
+func CurrentGitContext() *GitContext {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return DetectGitContext(dir)
+}
+
+// GitCommits lists the commits made in the session's repository between start
+// and end, oldest first. Only commits by the configured git user are included
+// when one is set. Outside a repository it returns nothing.
+func GitCommits(ctx *GitContext, start, end time.Time) ([]Commit, error) {
+	if ctx == nil || ctx.Root == "" {
+		return nil, nil
+	}
+
+	args := []string{"log", "--all", "--reverse", "--format=%H%x09%s",
+		"--since=" + start.Format(time.RFC3339), "--until=" + end.Format(time.RFC3339)}
+	if email, err := runGit(ctx.Root, "config", "user.email"); err == nil && email != "" {
+		args = append(args, "--author="+email)
+	}
+	out, err := runGit(ctx.Root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(out, "\n") {
+		hash, subject, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		commits = append(commits, Commit{Hash: hash, Subject: subject})
+	}
+	return commits, nil
+}
+
+// addCommits records the commits made during a logged session. Failing to run
+// git is not an error worth losing the entry over, so it is ignored.
+func addCommits(entry *LogEntry) {
+	entry.Commits, _ = GitCommits(entry.Git, entry.StartTime, entry.EndTime)
+}
+
+// RepoFilter selects log entries by the repository and branch they were started in
+type RepoFilter struct {
+	// Repo matches the repository's name or its root directory
+	Repo string
+	// Branch matches the branch name, and may contain glob patterns like "feature/*"
+	Branch string
+}
+
+// Matches reports whether an entry passes the filter
+func (f RepoFilter) Matches(entry LogEntry) bool {
+	if f.Repo != "" {
+		if entry.Git == nil || (f.Repo != entry.Git.Repo() && filepath.Clean(f.Repo) != entry.Git.Root) {
+			return false
+		}
+	}
+	if f.Branch != "" {
+		if entry.Git == nil {
+			return false
+		}
+		if matched, _ := path.Match(f.Branch, entry.Git.Branch); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByRepo returns the entries that pass the filter
+func FilterByRepo(entries []LogEntry, filter RepoFilter) []LogEntry {
+	if filter.Repo == "" && filter.Branch == "" {
+		return entries
+	}
+	filtered := []LogEntry{}
+	for _, entry := range entries {
+		if filter.Matches(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
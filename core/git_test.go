@@ -0,0 +1,106 @@
+package core
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// initTestRepo creates a git repository with a single commit on branch main
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "dev@example.com"},
+		{"config", "user.name", "Dev"},
+		{"remote", "add", "upstream", "https://example.com/repo.git"},
+		{"commit", "-q", "--allow-empty", "-m", "Fix login"},
+	} {
+		if _, err := runGit(dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func TestDetectGitContext(t *testing.T) {
+	dir := initTestRepo(t)
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := DetectGitContext(dir)
+	if ctx.Root != root || ctx.Branch != "main" || ctx.Remote != "upstream" {
+		t.Errorf("DetectGitContext() = %+v", ctx)
+	}
+	if ctx.Repo() != filepath.Base(root) {
+		t.Errorf("Repo() = %q, want %q", ctx.Repo(), filepath.Base(root))
+	}
+
+	outside := DetectGitContext(t.TempDir())
+	if outside.Root != "" || outside.Dir == "" {
+		t.Errorf("DetectGitContext() outside a repository = %+v", outside)
+	}
+}
+
+func TestGitCommits(t *testing.T) {
+	dir := initTestRepo(t)
+	ctx := DetectGitContext(dir)
+	now := time.Now()
+
+	commits, err := GitCommits(ctx, now.Add(-time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("GitCommits() error = %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "Fix login" || len(commits[0].ShortHash()) != 7 {
+		t.Errorf("GitCommits() = %+v", commits)
+	}
+
+	commits, err = GitCommits(ctx, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	if err != nil || len(commits) != 0 {
+		t.Errorf("GitCommits() before the commit = %+v, %v", commits, err)
+	}
+
+	if commits, err := GitCommits(nil, now, now); commits != nil || err != nil {
+		t.Errorf("GitCommits(nil) = %+v, %v", commits, err)
+	}
+}
+
+func TestRepoFilter(t *testing.T) {
+	entries := []LogEntry{
+		{Tag: "A", Git: &GitContext{Dir: "/src/flow", Root: "/src/flow", Branch: "main"}},
+		{Tag: "B", Git: &GitContext{Dir: "/src/flow/core", Root: "/src/flow", Branch: "feature/query"}},
+		{Tag: "C", Git: &GitContext{Dir: "/src/site", Root: "/src/site", Branch: "main"}},
+		{Tag: "D", Git: &GitContext{Dir: "/tmp"}},
+		{Tag: "E"},
+	}
+
+	tests := []struct {
+		filter RepoFilter
+		want   string
+	}{
+		{RepoFilter{}, "ABCDE"},
+		{RepoFilter{Repo: "flow"}, "AB"},
+		{RepoFilter{Repo: "/src/site/"}, "C"},
+		{RepoFilter{Branch: "main"}, "AC"},
+		{RepoFilter{Repo: "flow", Branch: "feature/*"}, "B"},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		for _, entry := range FilterByRepo(entries, tt.filter) {
+			got += entry.Tag
+		}
+		if got != tt.want {
+			t.Errorf("FilterByRepo(%+v) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // RunHook executes a custom script for a given event
@@ -16,6 +18,43 @@ func RunHook(event string, args ...string) {
 		return
 	}
 
+	runHookScript(hookPath, args...)
+}
+
+// RunSessionHook runs the hook for an event and then, if the session was started
+// from a preset with its own script for the event, that script too.
+func RunSessionHook(session Session, event string, args ...string) {
+	RunHook(event, args...)
+	if session.Preset == "" {
+		return
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return
+	}
+	script := config.Presets[session.Preset].Hooks[event]
+	if script == "" {
+		return
+	}
+
+	// Scripts can be given relative to the home or hooks directory
+	if strings.HasPrefix(script, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			script = filepath.Join(home, script[2:])
+		}
+	} else if !filepath.IsAbs(script) {
+		hookPath, err := getHookScriptPath(script)
+		if err != nil {
+			return
+		}
+		script = hookPath
+	}
+	runHookScript(script, args...)
+}
+
+// runHookScript runs an executable hook script, if it exists
+func runHookScript(hookPath string, args ...string) {
 	// Check if the hook script exists and is executable.
 	info, err := os.Stat(hookPath)
 	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
@@ -25,6 +64,10 @@ func RunHook(event string, args ...string) {
 
 	// Execute the hook script.
 	cmd := exec.Command(hookPath, args...)
+	if sessionLockHeld.Load() {
+		// Let flow commands run by the hook use the lock we are holding
+		cmd.Env = append(os.Environ(), lockHeldEnv+"="+strconv.Itoa(os.Getpid()))
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	_ = cmd.Run() // We run hooks on a best-effort basis. Ignore errors.
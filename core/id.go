@@ -0,0 +1,123 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// crockford is the base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// shortIDLength is the number of trailing ID characters shown to users
+const shortIDLength = 8
+
+// NewID generates a new ULID for the given time
+func NewID(t time.Time) string {
+	var entropy [10]byte
+	if _, err := rand.Read(entropy[:]); err != nil {
+		// Fall back to a time-derived value; uniqueness within a
+		// millisecond is all we lose.
+		sum := sha256.Sum256([]byte(t.String()))
+		copy(entropy[:], sum[:])
+	}
+	return encodeULID(t, entropy)
+}
+
+// legacyID derives a stable ID for a log line written before entries carried
+// IDs. The same line always yields the same ID, so it can be used to target
+// the entry in later commands.
+func legacyID(entry LogEntry, line string) string {
+	sum := sha256.Sum256([]byte(line))
+	var entropy [10]byte
+	copy(entropy[:], sum[:])
+	return encodeULID(entry.StartTime, entropy)
+}
+
+// encodeULID encodes a 48-bit millisecond timestamp and 80 bits of entropy
+// as a 26 character Crockford base32 string.
+func encodeULID(t time.Time, entropy [10]byte) string {
+	var data [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		data[i] = byte(ms)
+		ms >>= 8
+	}
+	copy(data[6:], entropy[:])
+
+	// 128 bits as 26 base32 characters; the first character carries only 3 bits
+	var out [26]byte
+	var acc uint32
+	bits := 2 // pad to 130 bits so the groups line up
+	pos := 0
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = crockford[(acc>>uint(bits))&0x1F]
+			pos++
+		}
+	}
+	return string(out[:])
+}
+
+// ShortID returns the abbreviated form of an ID shown in listings
+func ShortID(id string) string {
+	if len(id) <= shortIDLength {
+		return strings.ToLower(id)
+	}
+	return strings.ToLower(id[len(id)-shortIDLength:])
+}
+
+// matchesID reports whether ref identifies the given ID, either in full or
+// by its short form. Matching is case-insensitive.
+func matchesID(id, ref string) bool {
+	if id == "" || ref == "" {
+		return false
+	}
+	id = strings.ToUpper(id)
+	ref = strings.ToUpper(ref)
+	return id == ref || (len(ref) >= 4 && strings.HasSuffix(id, ref))
+}
+
+// parseLogLine decodes a JSON Lines log entry, upgrading entries written by
+// older versions to the current schema.
+func parseLogLine(line string) (LogEntry, error) {
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return entry, err
+	}
+	upgradeEntry(&entry, line)
+	return entry, nil
+}
+
+// FindLogEntry looks up a single log entry by its full or short ID
+func FindLogEntry(ref string) (LogEntry, error) {
+	reader, err := NewLogReader()
+	if err != nil {
+		return LogEntry{}, err
+	}
+	// A full ID is unique, so the search can stop at the first match; a
+	// short one must be checked against every entry for ambiguity
+	q := LogQuery{Where: func(entry LogEntry) bool { return matchesID(entry.ID, ref) }}
+	if len(ref) == 26 {
+		q.Limit = 1
+	}
+	matches, err := reader.Query(q)
+	if err != nil {
+		return LogEntry{}, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return LogEntry{}, fmt.Errorf("no log entry with ID %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return LogEntry{}, fmt.Errorf("ID %q is ambiguous (%d entries match)", ref, len(matches))
+	}
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewID(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	id1 := NewID(now)
+	id2 := NewID(now)
+
+	if len(id1) != 26 {
+		t.Errorf("Expected a 26 character ULID, got %q", id1)
+	}
+	if id1 == id2 {
+		t.Errorf("Expected unique IDs, got %q twice", id1)
+	}
+	// IDs generated later sort after earlier ones
+	if later := NewID(now.Add(time.Second)); later <= id1 {
+		t.Errorf("Expected %q to sort after %q", later, id1)
+	}
+}
+
+func TestMatchesID(t *testing.T) {
+	id := "01HXA3J5K8ZC7N4Q2W9R6T3Y1M"
+	tests := []struct {
+		ref  string
+		want bool
+	}{
+		{id, true},
+		{"01hxa3j5k8zc7n4q2w9r6t3y1m", true},
+		{ShortID(id), true},
+		{"t3y1m", true},
+		{"1m", false}, // too short to be meaningful
+		{"ZZZZZZZZ", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesID(id, tt.ref); got != tt.want {
+			t.Errorf("matchesID(%q, %q) = %v, want %v", id, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestLegacyEntriesGetStableIDs(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	legacy := LogEntry{Tag: "legacy", StartTime: start, EndTime: start.Add(time.Hour), Duration: time.Hour}
+	createTestMonthFile(t, filepath.Join(tempDir, "flow", "logs"), "202405_sessions.jsonl", []LogEntry{legacy})
+
+	if err := LogSession(LogEntry{Tag: "new", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), Duration: time.Hour}); err != nil {
+		t.Fatalf("LogSession() error = %v", err)
+	}
+
+	reader, _ := NewLogReader()
+	first, err := reader.ReadAllEntries()
+	if err != nil {
+		t.Fatalf("ReadAllEntries() error = %v", err)
+	}
+	second, _ := reader.ReadAllEntries()
+
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(first))
+	}
+	for i := range first {
+		if first[i].ID == "" {
+			t.Errorf("Entry %q has no ID", first[i].Tag)
+		}
+		if first[i].ID != second[i].ID {
+			t.Errorf("ID for %q changed between reads: %q vs %q", first[i].Tag, first[i].ID, second[i].ID)
+		}
+	}
+
+	// New entries have their ID written to disk
+	logPath, _ := GetLogPath(start)
+	data, _ := os.ReadFile(logPath)
+	var stored []LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err == nil {
+			stored = append(stored, entry)
+		}
+	}
+	if len(stored) != 2 || stored[0].ID != "" || stored[1].ID == "" {
+		t.Errorf("Expected only the new entry to carry a stored ID, got %+v", stored)
+	}
+
+	// Both entries can be found and deleted by their short ID
+	found, err := FindLogEntry(ShortID(first[1].ID))
+	if err != nil {
+		t.Fatalf("FindLogEntry() error = %v", err)
+	}
+	if found.Tag != "legacy" {
+		t.Errorf("FindLogEntry() found %q, want 'legacy'", found.Tag)
+	}
+	if err := DeleteLogEntry(found); err != nil {
+		t.Fatalf("DeleteLogEntry() error = %v", err)
+	}
+	remaining, _ := reader.ReadAllEntries()
+	if len(remaining) != 1 || remaining[0].Tag != "new" {
+		t.Errorf("Expected only 'new' to remain, got %+v", remaining)
+	}
+
+	if _, err := FindLogEntry("zzzzzzzz"); err == nil {
+		t.Error("Expected an error for an unknown ID")
+	}
+}
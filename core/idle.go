@@ -0,0 +1,47 @@
+package core
+
+import (
+	"time"
+)
+
+// IdleReason is the pause reason recorded for a gap with no heartbeat
+const IdleReason = "idle"
+
+// ApplyIdleTimeout pauses a running session as of its last heartbeat if it
+// has gone longer than timeout without one, so the idle gap counts as paused
+// time. Sessions that have never received a heartbeat are left alone. It
+// reports whether the session was paused.
+func ApplyIdleTimeout(session *Session, timeout time.Duration, now time.Time) bool {
+	if timeout <= 0 || session.IsPaused || session.LastActivity.IsZero() {
+		return false
+	}
+	if now.Sub(session.LastActivity) <= timeout {
+		return false
+	}
+
+	session.IsPaused = true
+	session.PausedAt = session.LastActivity
+	session.Pauses = append(session.Pauses, Pause{Start: session.LastActivity, Reason: IdleReason})
+	return true
+}
+
+// IsIdlePaused reports whether the session is paused because it went idle
+func IsIdlePaused(session Session) bool {
+	n := len(session.Pauses)
+	return session.IsPaused && n > 0 && session.Pauses[n-1].End.IsZero() && session.Pauses[n-1].Reason == IdleReason
+}
+
+// RecordHeartbeat notes activity on the session at now. An idle gap since the
+// previous heartbeat is recorded as a pause, and a session paused for being
+// idle resumes. It reports whether the session resumed.
+func RecordHeartbeat(session *Session, timeout time.Duration, now time.Time) bool {
+	ApplyIdleTimeout(session, timeout, now)
+
+	resumed := false
+	if IsIdlePaused(*session) {
+		ResumeSession(session, now)
+		resumed = true
+	}
+	session.LastActivity = now
+	return resumed
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyIdleTimeout(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	lastActivity := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		name       string
+		session    Session
+		timeout    time.Duration
+		now        time.Time
+		wantPaused bool
+	}{
+		{"disabled", Session{StartTime: start, LastActivity: lastActivity}, 0, lastActivity.Add(time.Hour), false},
+		{"no heartbeat yet", Session{StartTime: start}, 15 * time.Minute, lastActivity.Add(time.Hour), false},
+		{"within timeout", Session{StartTime: start, LastActivity: lastActivity}, 15 * time.Minute, lastActivity.Add(10 * time.Minute), false},
+		{"already paused", Session{StartTime: start, LastActivity: lastActivity, IsPaused: true, PausedAt: lastActivity}, 15 * time.Minute, lastActivity.Add(time.Hour), false},
+		{"idle", Session{StartTime: start, LastActivity: lastActivity}, 15 * time.Minute, lastActivity.Add(time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := tt.session
+			paused := ApplyIdleTimeout(&session, tt.timeout, tt.now)
+			if paused != tt.wantPaused {
+				t.Fatalf("ApplyIdleTimeout() = %v, want %v", paused, tt.wantPaused)
+			}
+			if !paused {
+				return
+			}
+			if !session.IsPaused || !session.PausedAt.Equal(lastActivity) {
+				t.Errorf("Expected the session to be paused at its last heartbeat, got %+v", session)
+			}
+			if !IsIdlePaused(session) {
+				t.Error("Expected the pause to be recorded as idle")
+			}
+		})
+	}
+}
+
+func TestRecordHeartbeat(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	session := Session{Tag: "Writing", StartTime: start}
+	timeout := 15 * time.Minute
+
+	if RecordHeartbeat(&session, timeout, start.Add(5*time.Minute)) {
+		t.Error("Expected the first heartbeat not to resume anything")
+	}
+
+	// Back from lunch: the gap becomes a closed idle pause
+	back := start.Add(2 * time.Hour)
+	if !RecordHeartbeat(&session, timeout, back) {
+		t.Error("Expected the heartbeat after an idle gap to resume the session")
+	}
+	if session.IsPaused {
+		t.Error("Expected the session to be running again")
+	}
+	if want := back.Sub(start.Add(5 * time.Minute)); session.TotalPaused != want {
+		t.Errorf("Expected %s of paused time, got %s", want, session.TotalPaused)
+	}
+	if len(session.Pauses) != 1 || session.Pauses[0].Reason != IdleReason || !session.Pauses[0].End.Equal(back) {
+		t.Errorf("Expected one closed idle pause, got %+v", session.Pauses)
+	}
+
+	// A manual pause is left alone
+	session.IsPaused = true
+	session.PausedAt = back.Add(time.Minute)
+	session.Pauses = append(session.Pauses, Pause{Start: session.PausedAt, Reason: "meeting"})
+	if RecordHeartbeat(&session, timeout, back.Add(2*time.Minute)) || !session.IsPaused {
+		t.Error("Expected a heartbeat not to resume a manually paused session")
+	}
+}
@@ -0,0 +1,337 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// indexFileName is the summary index kept in the log directory
+	indexFileName = "index.json"
+	// indexVersion changes whenever the index format does; an index of
+	// another version is rebuilt
+	indexVersion = 1
+)
+
+// logIndex holds a summary of each monthly log file, by file name
+type logIndex struct {
+	Version int                    `json:"version"`
+	Months  map[string]*monthIndex `json:"months"`
+}
+
+// monthIndex summarises one monthly log file by status. Size and ModTime
+// identify the version of the file it was built from; once the file changes
+// without the index being updated, the summary is rebuilt when next read.
+type monthIndex struct {
+	Size     int64               `json:"size"`
+	ModTime  time.Time           `json:"mod_time"`
+	Statuses map[string]*Summary `json:"statuses"`
+}
+
+// IndexReport summarises a rebuild of the summary index
+type IndexReport struct {
+	Path     string
+	Files    int
+	Sessions int
+}
+
+// Reindex rebuilds the summary index of the configured store
+func Reindex() (IndexReport, error) {
+	var report IndexReport
+	err := withStore(func(store Store) error {
+		var err error
+		report, err = store.Reindex()
+		return err
+	})
+	return report, err
+}
+
+// matches reports whether the summary was built from the file as it is now
+func (m *monthIndex) matches(info os.FileInfo) bool {
+	return m.Size == info.Size() && m.ModTime.Equal(info.ModTime())
+}
+
+// add counts an entry under its status
+func (m *monthIndex) add(entry LogEntry) {
+	if m.Statuses == nil {
+		m.Statuses = make(map[string]*Summary)
+	}
+	summary, ok := m.Statuses[entry.Status]
+	if !ok {
+		summary = &Summary{}
+		m.Statuses[entry.Status] = summary
+	}
+	summary.add(entry)
+}
+
+// remove takes an entry out of its status's summary, reporting false if it can't
+func (m *monthIndex) remove(entry LogEntry) bool {
+	summary, ok := m.Statuses[entry.Status]
+	if !ok || !summary.remove(entry) {
+		return false
+	}
+	if summary.Total.Count == 0 {
+		delete(m.Statuses, entry.Status)
+	}
+	return true
+}
+
+// summarizeLogFile builds the summary of a monthly log file
+func summarizeLogFile(path string) (*monthIndex, error) {
+	// Take the file's version before reading it, so a change made while it
+	// is read leaves the summary out of date rather than wrong
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, _, err := readLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	month := &monthIndex{Size: info.Size(), ModTime: info.ModTime()}
+	for _, entry := range entries {
+		month.add(entry)
+	}
+	return month, nil
+}
+
+// indexPath is where the store's summary index is kept. In sync mode each
+// device keeps its own, since they are written as sessions are logged.
+func (s *jsonlStore) indexPath() string {
+	if s.device != "" {
+		return filepath.Join(s.logDir, strings.TrimSuffix(indexFileName, ".json")+"."+s.device+".json")
+	}
+	return filepath.Join(s.logDir, indexFileName)
+}
+
+// isIndexFile reports whether a file in the log directory is a summary index
+func isIndexFile(name string) bool {
+	return name == indexFileName || (strings.HasPrefix(name, "index.") && strings.HasSuffix(name, ".json"))
+}
+
+// lockIndex takes an exclusive lock on the summary index while it is updated
+func (s *jsonlStore) lockIndex() (func(), error) {
+	return acquireLock(filepath.Join(s.logDir, ".index.lock"))
+}
+
+// loadIndex reads the summary index. A missing, unreadable or outdated index
+// is treated as empty, to be rebuilt as files are read.
+func (s *jsonlStore) loadIndex() *logIndex {
+	index := &logIndex{Version: indexVersion, Months: make(map[string]*monthIndex)}
+	data, err := os.ReadFile(s.indexPath())
+	if err != nil {
+		return index
+	}
+	var stored logIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != indexVersion || stored.Months == nil {
+		return index
+	}
+	return &stored
+}
+
+// saveIndex atomically replaces the summary index
+func (s *jsonlStore) saveIndex(index *logIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(s.logDir, ".index_")
+	if err != nil {
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := os.Rename(tempFile.Name(), s.indexPath()); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	return nil
+}
+
+// storeIndexMonths records rebuilt summaries in the index; a nil summary drops
+// the file from it. The index is only a cache, so failing to update it is not
+// an error: the summaries are rebuilt on the next read instead.
+func (s *jsonlStore) storeIndexMonths(months map[string]*monthIndex) {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	index := s.loadIndex()
+	for name, month := range months {
+		if month == nil {
+			delete(index.Months, name)
+		} else {
+			index.Months[name] = month
+		}
+	}
+	_ = s.saveIndex(index)
+}
+
+// updateIndex runs write against a monthly log file and applies the entries
+// it added and removed to the file's summary. A summary that was already out
+// of date, or can't be updated without rereading the file, is dropped to be
+// rebuilt when next read.
+func (s *jsonlStore) updateIndex(path string, write func() (added, removed []LogEntry, err error)) error {
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index := s.loadIndex()
+	name := filepath.Base(path)
+	month, known := index.Months[name]
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		// A new monthly file starts with an empty summary
+		month, known = &monthIndex{}, true
+	case err != nil || !known || !month.matches(info):
+		month = nil
+	}
+
+	added, removed, err := write()
+	if err != nil {
+		return err
+	}
+	if month != nil {
+		for _, entry := range removed {
+			if !month.remove(entry) {
+				month = nil
+				break
+			}
+		}
+	}
+	if month != nil {
+		for _, entry := range added {
+			month.add(entry)
+		}
+		if info, err := os.Stat(path); err == nil {
+			month.Size, month.ModTime = info.Size(), info.ModTime()
+		} else {
+			month = nil
+		}
+	}
+
+	if month == nil {
+		if !known {
+			return nil
+		}
+		delete(index.Months, name)
+	} else {
+		index.Months[name] = month
+	}
+	// The log has been written; a stale index is rebuilt on the next read
+	_ = s.saveIndex(index)
+	return nil
+}
+
+// Summarize totals the entries selected by q. Months wholly inside q's range
+// are taken from the summary index, rebuilding the summaries of files that
+// changed; only the files at the ends of the range are read entry by entry.
+// Queries that filter on more than time and status read every entry.
+func (s *jsonlStore) Summarize(q LogQuery) (*Summary, error) {
+	if !q.indexable() {
+		return summarize(s.Entries(q))
+	}
+	files, err := s.logFiles(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	index := s.loadIndex()
+	rebuilt := make(map[string]*monthIndex)
+	summary := &Summary{}
+	for _, file := range files {
+		month, ok := logFileMonth(file)
+		// Entries are filed by their end time in the time zone they were
+		// logged in, so a month's file holds entries up to a day either side
+		inside := ok && (q.From.IsZero() || !month.AddDate(0, 0, -1).Before(q.From)) &&
+			(q.To.IsZero() || !month.AddDate(0, 1, 1).After(q.To))
+		if !inside {
+			entries, _, err := readLogFile(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: error reading %s: %v\n", file, err)
+				continue
+			}
+			for _, entry := range entries {
+				if q.Matches(entry) {
+					summary.add(entry)
+				}
+			}
+			continue
+		}
+
+		name := filepath.Base(file)
+		summaries, ok := index.Months[name]
+		if info, err := os.Stat(file); !ok || err != nil || !summaries.matches(info) {
+			summaries, err = summarizeLogFile(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: error reading %s: %v\n", file, err)
+				continue
+			}
+			rebuilt[name] = summaries
+		}
+		for status, statusSummary := range summaries.Statuses {
+			if q.Statuses.Matches(status) {
+				summary.merge(statusSummary)
+			}
+		}
+	}
+
+	if len(rebuilt) > 0 {
+		s.storeIndexMonths(rebuilt)
+	}
+	return summary, nil
+}
+
+// Reindex rebuilds the summary of every monthly log file
+func (s *jsonlStore) Reindex() (IndexReport, error) {
+	report := IndexReport{Path: s.indexPath()}
+	files, err := s.logFiles(time.Time{}, time.Time{})
+	if err != nil {
+		return report, err
+	}
+	sort.Strings(files)
+
+	unlock, err := s.lockIndex()
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	index := &logIndex{Version: indexVersion, Months: make(map[string]*monthIndex)}
+	for _, file := range files {
+		month, err := summarizeLogFile(file)
+		if err != nil {
+			return report, err
+		}
+		index.Months[filepath.Base(file)] = month
+		report.Files++
+		for _, summary := range month.Statuses {
+			report.Sessions += summary.Total.Count
+		}
+	}
+	if err := ensureDir(report.Path); err != nil {
+		return report, err
+	}
+	return report, s.saveIndex(index)
+}
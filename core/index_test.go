@@ -0,0 +1,105 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummaryIndex(t *testing.T) {
+	store := openTestStore(t, StorageJSONL).(*jsonlStore)
+
+	day := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2025, month, d, hour, 0, 0, 0, time.Local)
+	}
+	entries := []LogEntry{
+		{Tag: "Writing", Project: "book", StartTime: day(1, 10, 9), EndTime: day(1, 10, 11), Duration: 2 * time.Hour, Status: StatusCompleted,
+			Pauses: []Pause{{Start: day(1, 10, 10), End: day(1, 10, 10).Add(10 * time.Minute), Reason: "Call"}}},
+		{Tag: "Review", StartTime: day(2, 3, 9), EndTime: day(2, 3, 10), Duration: time.Hour, Status: StatusAbandoned},
+		{Tag: "Writing", Project: "book/ch1", StartTime: day(2, 14, 9), EndTime: day(2, 14, 12), Duration: 3 * time.Hour, Status: StatusCompleted},
+		{Tag: "Planning", StartTime: day(3, 1, 9), EndTime: day(3, 1, 10), Duration: time.Hour, Status: StatusManual},
+	}
+	for _, entry := range entries {
+		if err := LogSession(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The index answers the same as reading every entry
+	check := func(name string, q LogQuery) {
+		t.Helper()
+		got, err := store.Summarize(q)
+		if err != nil {
+			t.Fatalf("%s: Summarize() error = %v", name, err)
+		}
+		want, err := summarize(store.Entries(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Summarize() = %+v, want %+v", name, got, want)
+		}
+	}
+	check("all", LogQuery{})
+	check("range", LogQuery{From: day(2, 10, 0), To: day(3, 1, 9)})
+	check("status", LogQuery{Statuses: StatusFilter{Exclude: []string{StatusAbandoned}}})
+
+	index := store.loadIndex()
+	if len(index.Months) != 3 {
+		t.Fatalf("Index has %d months, want 3", len(index.Months))
+	}
+	febPath, _ := GetLogPath(day(2, 1, 0))
+	fresh := func() bool {
+		info, err := os.Stat(febPath)
+		month, ok := store.loadIndex().Months[filepath.Base(febPath)]
+		return err == nil && ok && month.matches(info)
+	}
+	if !fresh() {
+		t.Error("Expected the index to be up to date after logging")
+	}
+
+	// Deleting and editing update the index in place
+	stored, err := collect(store.Entries(LogQuery{From: day(2, 1, 0), To: day(3, 1, 0)}))
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("Entries(February) = %+v, %v", stored, err)
+	}
+	if err := DeleteLogEntry(stored[1]); err != nil {
+		t.Fatal(err)
+	}
+	edited := stored[0]
+	edited.Tag = "Editing"
+	if err := store.Update(stored[0], edited); err != nil {
+		t.Fatal(err)
+	}
+	if !fresh() {
+		t.Error("Expected the index to be up to date after deleting and editing")
+	}
+	check("after delete", LogQuery{})
+	summary, _ := store.Summarize(LogQuery{})
+	if summary.Total.Count != 3 || summary.Tags["Editing"].Count != 1 || summary.Tags["Review"].Count != 0 {
+		t.Errorf("Summarize() after delete = %+v", summary)
+	}
+
+	// A file changed behind the store's back is summarised again
+	line := `{"tag":"Imported","start_time":"2025-02-20T09:00:00Z","end_time":"2025-02-20T10:00:00Z","duration":3600000000000,"status":"imported"}`
+	file, err := os.OpenFile(febPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+	check("after external change", LogQuery{})
+	if !fresh() {
+		t.Error("Expected reading to rebuild the changed month")
+	}
+
+	report, err := store.Reindex()
+	if err != nil || report.Files != 3 || report.Sessions != 4 {
+		t.Errorf("Reindex() = %+v, %v", report, err)
+	}
+	check("after reindex", LogQuery{})
+}
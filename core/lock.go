@@ -0,0 +1,136 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// lockTimeout bounds how long a command waits for another one to finish
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval is how often a busy lock is retried
+	lockRetryInterval = 10 * time.Millisecond
+	// lockHeldEnv is set to the process ID of a flow command holding the
+	// session lock for the hook scripts it runs, so flow commands run from a
+	// hook don't wait on their own parent.
+	lockHeldEnv = "FLOW_LOCK_HELD"
+)
+
+// errLockBusy is returned by tryLockFile when another process holds the lock
+var errLockBusy = errors.New("lock is held by another process")
+
+// sessionLockHeld records whether this process currently holds the session lock
+var sessionLockHeld atomic.Bool
+
+// acquireLock takes an exclusive advisory lock on the lock file at path,
+// waiting up to lockTimeout for other holders. The returned function releases
+// the lock and may be called more than once.
+func acquireLock(path string) (func(), error) {
+	if err := ensureDir(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := tryLockFile(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockBusy) || time.Now().After(deadline) {
+			_ = file.Close()
+			if errors.Is(err, errLockBusy) {
+				return nil, fmt.Errorf("timed out waiting for %s; is another flow command running?", filepath.Base(path))
+			}
+			return nil, err
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			_ = unlockFile(file)
+			_ = file.Close()
+		})
+	}, nil
+}
+
+// LockSession takes an exclusive lock on the session file for the duration of a
+// read-modify-write. The returned function releases it and may be called more
+// than once. Commands started from a hook share their parent's lock instead of
+// waiting for it, but only while the parent still holds it, so a process the
+// hook leaves running in the background can't skip the lock later.
+func LockSession() (func(), error) {
+	path, err := GetSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	lockPath, holderPath := path+".lock", path+".lock.pid"
+	if parent := os.Getenv(lockHeldEnv); parent != "" && parent == lockHolder(lockPath, holderPath) {
+		return func() {}, nil
+	}
+
+	release, err := acquireLock(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	// The holder is kept beside the lock file, which can't be read while
+	// locked on every platform
+	if err := os.WriteFile(holderPath, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		release()
+		return nil, err
+	}
+	sessionLockHeld.Store(true)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sessionLockHeld.Store(false)
+			_ = os.Remove(holderPath)
+			release()
+		})
+	}, nil
+}
+
+// lockHolder returns the process ID of the flow command holding the session
+// lock, and "" when the lock is free
+func lockHolder(lockPath, holderPath string) string {
+	file, err := os.OpenFile(lockPath, os.O_RDWR, 0644)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	if err := tryLockFile(file); err == nil {
+		// A holder left behind by a command that crashed holds nothing
+		_ = unlockFile(file)
+		return ""
+	}
+	data, err := os.ReadFile(holderPath)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// lockLogs takes an exclusive lock on the log directory while a log file is
+// appended to or rewritten.
+func lockLogs() (func(), error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return nil, err
+	}
+	return acquireLock(filepath.Join(logDir, ".lock"))
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogSessionConcurrent(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	const writers = 50
+	start := time.Now().Add(-time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := LogEntry{
+				Tag:       "concurrent",
+				StartTime: start.Add(time.Duration(i) * time.Second),
+				EndTime:   start.Add(time.Duration(i)*time.Second + time.Minute),
+				Duration:  time.Minute,
+			}
+			if err := LogSession(entry); err != nil {
+				t.Errorf("LogSession() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	reader, _ := NewLogReader()
+	entries, err := reader.ReadAllEntries()
+	if err != nil {
+		t.Fatalf("ReadAllEntries() error = %v", err)
+	}
+	if len(entries) != writers {
+		t.Errorf("Expected %d intact entries, got %d", writers, len(entries))
+	}
+}
+
+func TestLockSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	unlock, err := LockSession()
+	if err != nil {
+		t.Fatalf("LockSession() error = %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := LockSession()
+		if err != nil {
+			t.Errorf("second LockSession() error = %v", err)
+			close(acquired)
+			return
+		}
+		close(acquired)
+		second()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second lock to wait while the first is held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	unlock() // Releasing twice is harmless
+
+	select {
+	case <-acquired:
+	case <-time.After(lockTimeout):
+		t.Fatal("Expected the second lock to be acquired once the first was released")
+	}
+
+	// Commands run from a hook share their parent's lock
+	unlock, _ = LockSession()
+	t.Setenv(lockHeldEnv, strconv.Itoa(os.Getpid()))
+	nested, err := LockSession()
+	if err != nil {
+		t.Fatalf("Expected LockSession() to succeed inside a hook, got %v", err)
+	}
+	nested()
+	if !sessionLockHeld.Load() {
+		t.Error("Expected the nested release to leave the parent's lock held")
+	}
+
+	// Once the parent has released it, the lock is no longer shared
+	unlock()
+	later, err := LockSession()
+	if err != nil || !sessionLockHeld.Load() {
+		t.Fatalf("Expected LockSession() after the parent exited to take the lock, got %v", err)
+	}
+	later()
+}
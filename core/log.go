@@ -1,11 +1,9 @@
 package core
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
+	"iter"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -15,239 +13,159 @@ const (
 	// Performance limits to prevent memory issues
 	defaultMaxEntries = 10
 	maxEntriesLimit   = 1000
-	warningThreshold  = 10000
 )
 
-// LogReader provides efficient reading of log entries from partitioned files
+// LogReader reads log entries from the configured store
 type LogReader struct {
-	logDir string
+	statuses StatusFilter
+	projects ProjectFilter
+	repos    RepoFilter
 }
 
 // NewLogReader creates a new log reader
 func NewLogReader() (*LogReader, error) {
-	logDir, err := GetLogDir()
-	if err != nil {
-		return nil, err
-	}
-	return &LogReader{logDir: logDir}, nil
+	return &LogReader{}, nil
 }
 
-// getRelevantLogFiles returns the list of log files to read based on filters
-func (lr *LogReader) getRelevantLogFiles(filterToday, filterWeek, filterMonth bool, targetMonth ...time.Time) ([]string, error) {
-	// Ensure logs directory exists
-	if _, err := os.Stat(lr.logDir); os.IsNotExist(err) {
-		return []string{}, nil
-	}
-
-	files, err := filepath.Glob(filepath.Join(lr.logDir, "*_sessions.jsonl"))
-	if err != nil {
-		return nil, err
-	}
-
-	if len(files) == 0 {
-		return []string{}, nil
-	}
+// SetStatusFilter limits the entries the reader returns to those passing filter.
+// Cancelled sessions are read from the cancel log when the filter includes them.
+func (lr *LogReader) SetStatusFilter(filter StatusFilter) {
+	lr.statuses = filter
+}
 
-	// If no filters, return all files
-	if !filterToday && !filterWeek && !filterMonth && len(targetMonth) == 0 {
-		return files, nil
-	}
+// SetProjectFilter limits the entries the reader returns to those in a project
+// and carrying the given labels.
+func (lr *LogReader) SetProjectFilter(filter ProjectFilter) {
+	lr.projects = filter
+}
 
-	now := time.Now()
-	var relevantFiles []string
+// SetRepoFilter limits the entries the reader returns to those started in a
+// repository or on a branch.
+func (lr *LogReader) SetRepoFilter(filter RepoFilter) {
+	lr.repos = filter
+}
 
-	for _, file := range files {
-		basename := filepath.Base(file)
-		// Extract YYYYMM from filename like "202507_sessions.jsonl"
-		if len(basename) < 6 {
-			continue
+// Entries streams the entries selected by q, in q's order, with the reader's
+// filters in place of q's. Only the log files overlapping q's range are read,
+// one at a time as the caller iterates, and reading stops once q's limit is
+// reached or the caller breaks out of the loop. Cancelled sessions are merged
+// in from the cancel log when the status filter includes them.
+func (lr *LogReader) Entries(q LogQuery) iter.Seq2[LogEntry, error] {
+	q.Statuses, q.Projects, q.Repos = lr.statuses, lr.projects, lr.repos
+	return func(yield func(LogEntry, error) bool) {
+		var cancelled []LogEntry
+		if q.Statuses.Matches(StatusCancelled) {
+			entries, err := ReadCancelledEntries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: error reading cancelled sessions: %v\n", err)
+			}
+			for _, entry := range entries {
+				if q.Matches(entry) {
+					cancelled = append(cancelled, entry)
+				}
+			}
+			q.sort(cancelled)
 		}
-		monthStr := basename[:6] // Extract YYYYMM
 
-		// Parse the month
-		fileDate, err := time.Parse("200601", monthStr)
+		store, err := OpenStore()
 		if err != nil {
-			continue // Skip malformed filenames
+			yield(LogEntry{}, err)
+			return
 		}
-
-		// Check if this file is relevant based on filters
-		if len(targetMonth) > 0 {
-			// Specific month requested
-			if fileDate.Year() == targetMonth[0].Year() && fileDate.Month() == targetMonth[0].Month() {
-				relevantFiles = append(relevantFiles, file)
+		defer func() {
+			_ = store.Close()
+		}()
+
+		out := emitter{q: q, yield: yield}
+		for entry, err := range store.Entries(q) {
+			if err != nil {
+				yield(LogEntry{}, err)
+				return
 			}
-		} else if filterToday {
-			// For today filter, include files from current month (filtering by actual date happens later)
-			if fileDate.Year() == now.Year() && fileDate.Month() == now.Month() {
-				relevantFiles = append(relevantFiles, file)
-			}
-		} else if filterWeek {
-			// For week filter, include files from current and potentially previous month
-			currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-			previousMonth := currentMonth.AddDate(0, -1, 0)
-
-			if (fileDate.Year() == currentMonth.Year() && fileDate.Month() == currentMonth.Month()) ||
-				(fileDate.Year() == previousMonth.Year() && fileDate.Month() == previousMonth.Month()) {
-				relevantFiles = append(relevantFiles, file)
+			for len(cancelled) > 0 && q.before(cancelled[0], entry) {
+				if !out.send(cancelled[0]) {
+					return
+				}
+				cancelled = cancelled[1:]
 			}
-		} else if filterMonth {
-			// Current month
-			if fileDate.Year() == now.Year() && fileDate.Month() == now.Month() {
-				relevantFiles = append(relevantFiles, file)
+			if !out.send(entry) {
+				return
 			}
-		} else if filterWeek || filterToday {
-			// For week/today filters, we might need current month and potentially previous month
-			currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-			previousMonth := currentMonth.AddDate(0, -1, 0)
-
-			if (fileDate.Year() == currentMonth.Year() && fileDate.Month() == currentMonth.Month()) ||
-				(fileDate.Year() == previousMonth.Year() && fileDate.Month() == previousMonth.Month()) {
-				relevantFiles = append(relevantFiles, file)
+		}
+		for _, entry := range cancelled {
+			if !out.send(entry) {
+				return
 			}
 		}
 	}
-
-	return relevantFiles, nil
 }
 
-// ReadRecentEntries reads the most recent entries efficiently
-func (lr *LogReader) ReadRecentEntries(limit int, filterToday, filterWeek bool) ([]LogEntry, error) {
-	entries, err := lr.readEntries(limit, filterToday, filterWeek, false, nil)
+// Query returns the entries selected by q with the reader's filters
+func (lr *LogReader) Query(q LogQuery) ([]LogEntry, error) {
+	entries, err := collect(lr.Entries(q))
 	if err != nil {
 		return nil, err
 	}
-
-	if filterToday || filterWeek {
-		now := time.Now()
-		filteredEntries := []LogEntry{}
-		for _, entry := range entries {
-			if filterToday && !isToday(entry.EndTime, now) {
-				continue
-			}
-			if filterWeek && !isThisWeek(entry.EndTime, now) {
-				continue
-			}
-			filteredEntries = append(filteredEntries, entry)
-		}
-		// Respect the original limit after filtering
-		if limit > 0 && len(filteredEntries) > limit {
-			return filteredEntries[:limit], nil
-		}
-		return filteredEntries, nil
-	}
-
 	return entries, nil
 }
 
-// ReadMonthEntries reads entries from a specific month
-func (lr *LogReader) ReadMonthEntries(month time.Time, limit int) ([]LogEntry, error) {
-	return lr.readEntries(limit, false, false, false, &month)
-}
-
-// ReadAllEntries reads all entries (use with caution for large datasets)
-func (lr *LogReader) ReadAllEntries() ([]LogEntry, error) {
-	return lr.readEntries(0, false, false, true, nil)
-}
-
-// readEntries is the internal method that handles all reading scenarios
-func (lr *LogReader) readEntries(limit int, filterToday, filterWeek, readAll bool, targetMonth *time.Time) ([]LogEntry, error) {
-	if limit > maxEntriesLimit && !readAll {
-		limit = maxEntriesLimit
-	}
-
-	// Determine which files to read
-	var files []string
-	var err error
-
-	if targetMonth != nil {
-		files, err = lr.getRelevantLogFiles(false, false, false, *targetMonth)
-	} else {
-		// Pass the filters to getRelevantLogFiles so it can select the right month files
-		files, err = lr.getRelevantLogFiles(filterToday, filterWeek, false)
-	}
-
+// Summarize totals the entries selected by q with the reader's filters. Unlike
+// Entries, it can answer from the store's summary index without reading the
+// entries themselves.
+func (lr *LogReader) Summarize(q LogQuery) (*Summary, error) {
+	q.Statuses, q.Projects, q.Repos = lr.statuses, lr.projects, lr.repos
+
+	var summary *Summary
+	err := withStore(func(store Store) error {
+		var err error
+		summary, err = store.Summarize(q)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if len(files) == 0 {
-		return []LogEntry{}, nil
-	}
-
-	var allEntries []LogEntry
-	totalLines := 0
-
-	// Sort files in reverse order (newest first) for better performance with limits
-	sort.Slice(files, func(i, j int) bool {
-		return files[i] > files[j] // Lexicographically, newer YYYYMM comes after older
-	})
-
-	for _, file := range files {
-		fileEntries, lines, err := lr.readSingleFile(file)
+	if q.Statuses.Matches(StatusCancelled) {
+		cancelled, err := ReadCancelledEntries()
 		if err != nil {
-			// Log error but continue with other files
-			fmt.Fprintf(os.Stderr, "Warning: error reading %s: %v\n", file, err)
-			continue
+			fmt.Fprintf(os.Stderr, "Warning: error reading cancelled sessions: %v\n", err)
 		}
-
-		allEntries = append(allEntries, fileEntries...)
-		totalLines += lines
-
-		// If we have enough entries and not reading all, break early
-		if !readAll && limit > 0 && len(allEntries) >= limit {
-			break
+		for _, entry := range cancelled {
+			if q.Matches(entry) {
+				summary.add(entry)
+			}
 		}
 	}
-
-	// Warn if we have a very large number of entries
-	if totalLines > warningThreshold {
-		fmt.Fprintf(os.Stderr, "⚠️  Large dataset detected (%d entries across %d files). Consider using more specific filters.\n", totalLines, len(files))
-	}
-
-	// Sort all entries by end time (most recent first)
-	sort.Slice(allEntries, func(i, j int) bool {
-		return allEntries[i].EndTime.After(allEntries[j].EndTime)
-	})
-
-	// Apply limit after sorting
-	if !readAll && limit > 0 && len(allEntries) > limit {
-		allEntries = allEntries[:limit]
-	}
-
-	return allEntries, nil
+	return summary, nil
 }
 
-// readSingleFile reads entries from a single log file
-func (lr *LogReader) readSingleFile(filePath string) (entries []LogEntry, lineCount int, err error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, 0, err
+// ReadRecentEntries reads the most recent entries, optionally only those from
+// today or this week
+func (lr *LogReader) ReadRecentEntries(limit int, filterToday, filterWeek bool) ([]LogEntry, error) {
+	q := LogQuery{Limit: min(limit, maxEntriesLimit)}
+	if filterToday {
+		q.From, q.To = periodRange("today", time.Now())
+	} else if filterWeek {
+		q.From, q.To = periodRange("week", time.Now())
 	}
-	defer func() {
-		if closeErr := file.Close(); err == nil {
-			err = closeErr
-		}
-	}()
-
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		lineCount++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	return lr.Query(q)
+}
 
-		var entry LogEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			// Skip malformed lines but continue processing
-			continue
-		}
+// ReadMonthEntries reads entries from a specific month
+func (lr *LogReader) ReadMonthEntries(month time.Time, limit int) ([]LogEntry, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	return lr.Query(LogQuery{From: from, To: from.AddDate(0, 1, 0), Limit: min(limit, maxEntriesLimit)})
+}
 
-		entries = append(entries, entry)
-	}
+// ReadRange reads the entries that ended within a date range. Only the
+// monthly files overlapping the range are read. A limit of 0 means no limit.
+func (lr *LogReader) ReadRange(dates DateRange, limit int) ([]LogEntry, error) {
+	return lr.Query(LogQuery{From: dates.From, To: dates.To, Limit: limit})
+}
 
-	return entries, lineCount, scanner.Err()
+// ReadAllEntries reads all entries (use with caution for large datasets)
+func (lr *LogReader) ReadAllEntries() ([]LogEntry, error) {
+	return lr.Query(LogQuery{})
 }
 
 // LogStats contains aggregated statistics
@@ -257,6 +175,8 @@ type LogStats struct {
 	AverageTime   time.Duration
 	TopActivities []ActivityStat
 	DateRange     string
+	Interruptions InterruptionStats
+	Projects      []ProjectStat
 }
 
 // ActivityStat represents statistics for a specific activity
@@ -266,6 +186,30 @@ type ActivityStat struct {
 	Count    int
 }
 
+// InterruptionStats summarizes the pauses recorded across log entries
+type InterruptionStats struct {
+	Count      int
+	TotalTime  time.Duration
+	Longest    Pause
+	TopReasons []ReasonStat
+}
+
+// ReasonStat represents how often and how long sessions were interrupted for a reason
+type ReasonStat struct {
+	Reason   string
+	Count    int
+	Duration time.Duration
+}
+
+// CalculateInterruptions computes interruption statistics from log entries
+func CalculateInterruptions(entries []LogEntry) InterruptionStats {
+	var summary Summary
+	for _, entry := range entries {
+		summary.add(entry)
+	}
+	return summary.Interruptions()
+}
+
 // CalculateStats computes statistics from log entries
 func CalculateStats(entries []LogEntry) LogStats {
 	if len(entries) == 0 {
@@ -328,64 +272,30 @@ func CalculateStats(entries []LogEntry) LogStats {
 		stats.TopActivities = stats.TopActivities[:10]
 	}
 
+	stats.Interruptions = CalculateInterruptions(entries)
+	stats.Projects = CalculateProjectRollup(entries)
+
 	return stats
 }
 
-// HandleLog handles the log command with improved performance
-func HandleLog(showStats, filterToday, filterWeek, filterMonth, showAll bool, monthStr string) {
+// HandleLog handles the log command with improved performance. Without a
+// date range, the most recent entries are shown.
+func HandleLog(showStats, showAll bool, dates DateRange, statuses StatusFilter, projects ProjectFilter, repos RepoFilter) {
 	reader, err := NewLogReader()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating log reader: %v\n", err)
 		os.Exit(1)
 	}
+	reader.SetStatusFilter(statuses)
+	reader.SetProjectFilter(projects)
+	reader.SetRepoFilter(repos)
 
-	var targetMonth *time.Time
-	if monthStr != "" {
-		if month, err := time.Parse("2006-01", monthStr); err == nil {
-			targetMonth = &month
-		} else {
-			// Handle case where monthStr is not a valid date, e.g. from args
-			if t, err := time.Parse("2006-01-02", monthStr); err == nil {
-				targetMonth = &t
-			} else {
-				fmt.Fprintf(os.Stderr, "Error: Invalid month format '%s'. Please use YYYY-MM.\n", monthStr)
-				os.Exit(1)
-			}
-		}
-	}
-
-	var entries []LogEntry
-	maxEntries := defaultMaxEntries // Use default unless --all is specified
-
-	if targetMonth != nil {
-		entries, err = reader.ReadMonthEntries(*targetMonth, maxEntries)
-	} else if showAll {
-		var allEntries []LogEntry
-		allEntries, err = reader.ReadAllEntries()
-		if err == nil && (filterToday || filterWeek || filterMonth) {
-			// If --all is combined with filters, apply them after loading
-			now := time.Now()
-			entries = []LogEntry{} // Reset entries to fill with filtered results
-			for _, entry := range allEntries {
-				if filterToday && !isToday(entry.EndTime, now) {
-					continue
-				}
-				if filterWeek && !isThisWeek(entry.EndTime, now) {
-					continue
-				}
-				if filterMonth && (entry.EndTime.Year() != now.Year() || entry.EndTime.Month() != now.Month()) {
-					continue
-				}
-				entries = append(entries, entry)
-			}
-		} else {
-			entries = allEntries
-		}
-	} else {
-		// Default path for recent entries, passing filters
-		entries, err = reader.ReadRecentEntries(maxEntries, filterToday, filterWeek)
+	q := LogQuery{From: dates.From, To: dates.To, Limit: defaultMaxEntries}
+	if showAll {
+		q.Limit = 0
 	}
 
+	entries, err := reader.Query(q)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading log entries: %v\n", err)
 		os.Exit(1)
@@ -397,26 +307,46 @@ func HandleLog(showStats, filterToday, filterWeek, filterMonth, showAll bool, mo
 	}
 
 	if showStats {
-		displayStats(entries, filterToday, filterWeek, filterMonth, targetMonth)
+		displayStats(entries, dates)
 	} else {
-		displayEntries(entries, filterToday, filterWeek, filterMonth, targetMonth, showAll)
+		displayEntries(entries, dates, showAll)
 	}
 }
 
+// periodHeadings names a range for the log's headings, in the entries list
+// and the statistics. Today, this week and calendar months get their names.
+func periodHeadings(dates DateRange, now time.Time) (string, string) {
+	month := time.Date(dates.From.Year(), dates.From.Month(), 1, 0, 0, 0, 0, dates.From.Location())
+	switch {
+	case dates.IsZero():
+		return "", "All Time"
+	case isPeriod(dates, "today", now):
+		return "Today's sessions", "Today"
+	case isPeriod(dates, "week", now):
+		return "This week's sessions", "This Week"
+	case isPeriod(dates, "month", now):
+		return "This month's sessions", "This Month"
+	case dates.From.Equal(month) && dates.To.Equal(month.AddDate(0, 1, 0)):
+		return month.Format("January 2006") + " sessions", month.Format("January 2006")
+	}
+	return "Sessions " + describeRange(dates), dates.String()
+}
+
+// isPeriod reports whether a range is the period periodRange names
+func isPeriod(dates DateRange, expr string, now time.Time) bool {
+	from, to := periodRange(expr, now)
+	return dates.From.Equal(from) && dates.To.Equal(to)
+}
+
 // displayEntries shows session entries in a user-friendly format
-func displayEntries(entries []LogEntry, filterToday, filterWeek, filterMonth bool, targetMonth *time.Time, showAll bool) {
+func displayEntries(entries []LogEntry, dates DateRange, showAll bool) {
 	// Determine header
-	period := "Recent sessions"
-	if targetMonth != nil {
-		period = fmt.Sprintf("%s sessions", targetMonth.Format("January 2006"))
-	} else if filterToday {
-		period = "Today's sessions"
-	} else if filterWeek {
-		period = "This week's sessions"
-	} else if filterMonth {
-		period = "This month's sessions"
-	} else if showAll {
-		period = "All sessions"
+	period, _ := periodHeadings(dates, time.Now())
+	if period == "" {
+		period = "Recent sessions"
+		if showAll {
+			period = "All sessions"
+		}
 	}
 
 	fmt.Printf("🌊 %s:\n\n", period)
@@ -428,11 +358,49 @@ func displayEntries(entries []LogEntry, filterToday, filterWeek, filterMonth boo
 			entry.StartTime.Format("15:04"),
 			entry.EndTime.Format("15:04"))
 
-		fmt.Printf("%s %s %s %s\n",
+		details := ""
+		if entry.Project != "" {
+			details += " " + entry.Project
+		}
+		for _, label := range entry.Labels {
+			details += " #" + label
+		}
+		if repo := entry.Git.Repo(); repo != "" {
+			details += " " + repo
+			if entry.Git.Branch != "" {
+				details += "@" + entry.Git.Branch
+			}
+		}
+		if details != "" {
+			details = fmt.Sprintf(" %s%s%s", Gray, strings.TrimSpace(details), Reset)
+		}
+
+		status := ""
+		if entry.Status != StatusCompleted {
+			status = fmt.Sprintf(" %s[%s]%s", Dim, entry.Status, Reset)
+		}
+
+		interruptions := ""
+		if n := len(entry.Pauses); n == 1 {
+			interruptions = fmt.Sprintf(" %s(1 interruption)%s", Dim, Reset)
+		} else if n > 1 {
+			interruptions = fmt.Sprintf(" %s(%d interruptions)%s", Dim, n, Reset)
+		}
+		if n := len(entry.Commits); n == 1 {
+			interruptions += fmt.Sprintf(" %s(1 commit)%s", Dim, Reset)
+		} else if n > 1 {
+			interruptions += fmt.Sprintf(" %s(%d commits)%s", Dim, n, Reset)
+		}
+
+		fmt.Printf("%s%s%s %s %s %s %s%s%s%s\n",
+			Gray, ShortID(entry.ID), Reset,
 			date,
 			timeRange,
 			FormatDuration(entry.Duration),
-			entry.Tag)
+			entry.Tag,
+			details,
+			status,
+			interruptions)
 	}
 
 	// Show summary
@@ -447,20 +415,11 @@ func displayEntries(entries []LogEntry, filterToday, filterWeek, filterMonth boo
 }
 
 // displayStats shows statistical analysis
-func displayStats(entries []LogEntry, filterToday, filterWeek, filterMonth bool, targetMonth *time.Time) {
+func displayStats(entries []LogEntry, dates DateRange) {
 	stats := CalculateStats(entries)
 
 	// Header based on filter
-	period := "All Time"
-	if targetMonth != nil {
-		period = targetMonth.Format("January 2006")
-	} else if filterToday {
-		period = "Today"
-	} else if filterWeek {
-		period = "This Week"
-	} else if filterMonth {
-		period = "This Month"
-	}
+	_, period := periodHeadings(dates, time.Now())
 
 	fmt.Printf("🌊 Deep Work Statistics (%s):\n\n", period)
 	fmt.Printf("Total time:     %s\n", FormatDuration(stats.TotalTime))
@@ -482,6 +441,51 @@ func displayStats(entries []LogEntry, filterToday, filterWeek, filterMonth bool,
 			fmt.Printf("  %d. %s (%d sessions, %s, %.1f%%)\n", i+1, activity.Tag, activity.Count, FormatDuration(activity.Duration), percentage)
 		}
 	}
+
+	displayProjects(stats.Projects, stats.TotalTime)
+	displayInterruptions(stats.Interruptions)
+}
+
+// displayProjects shows time per project as a tree, each project including its subprojects
+func displayProjects(projects []ProjectStat, totalTime time.Duration) {
+	if len(projects) == 0 {
+		return
+	}
+
+	fmt.Printf("\nProjects:\n")
+	for _, project := range projects {
+		percentage := 0.0
+		if totalTime > 0 {
+			percentage = (float64(project.Duration) / float64(totalTime)) * 100
+		}
+		fmt.Printf("  %s%s (%d sessions, %s, %.1f%%)\n",
+			strings.Repeat("  ", project.Depth), project.Name(),
+			project.Count, FormatDuration(project.Duration), percentage)
+	}
+}
+
+// displayInterruptions shows how often and why sessions were interrupted
+func displayInterruptions(stats InterruptionStats) {
+	if stats.Count == 0 {
+		return
+	}
+
+	fmt.Printf("\nInterruptions:  %d (%s paused)\n", stats.Count, FormatDuration(stats.TotalTime))
+	longest := FormatDuration(stats.Longest.Duration())
+	if stats.Longest.Reason != "" {
+		longest = fmt.Sprintf("%s (%s)", longest, stats.Longest.Reason)
+	}
+	fmt.Printf("Longest:        %s\n", longest)
+
+	if len(stats.TopReasons) > 0 {
+		fmt.Printf("\nTop interruption reasons:\n")
+		for i, reason := range stats.TopReasons {
+			if i >= 5 {
+				break
+			}
+			fmt.Printf("  %d. %s (%d times, %s)\n", i+1, reason.Reason, reason.Count, FormatDuration(reason.Duration))
+		}
+	}
 }
 
 // Date filtering helper functions
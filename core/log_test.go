@@ -220,6 +220,42 @@ func TestEmptyStatsCalculation(t *testing.T) {
 	}
 }
 
+func TestCalculateInterruptions(t *testing.T) {
+	base := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	pause := func(startMin, lengthMin int, reason string) Pause {
+		start := base.Add(time.Duration(startMin) * time.Minute)
+		return Pause{Start: start, End: start.Add(time.Duration(lengthMin) * time.Minute), Reason: reason}
+	}
+
+	entries := []LogEntry{
+		{Tag: "coding", Pauses: []Pause{pause(10, 5, "slack ping"), pause(40, 20, "meeting")}},
+		{Tag: "writing", Pauses: []Pause{pause(120, 3, "slack ping"), pause(150, 2, "")}},
+		{Tag: "review"},
+	}
+
+	stats := CalculateInterruptions(entries)
+
+	if stats.Count != 4 {
+		t.Errorf("Expected 4 interruptions, got %d", stats.Count)
+	}
+	if stats.TotalTime != 30*time.Minute {
+		t.Errorf("Expected 30m of interruptions, got %v", stats.TotalTime)
+	}
+	if stats.Longest.Reason != "meeting" || stats.Longest.Duration() != 20*time.Minute {
+		t.Errorf("Expected longest interruption to be the 20m meeting, got %+v", stats.Longest)
+	}
+	if len(stats.TopReasons) != 2 {
+		t.Fatalf("Expected 2 interruption reasons, got %d", len(stats.TopReasons))
+	}
+	if stats.TopReasons[0].Reason != "slack ping" || stats.TopReasons[0].Count != 2 {
+		t.Errorf("Expected 'slack ping' to be the top reason, got %+v", stats.TopReasons[0])
+	}
+
+	if CalculateStats(entries).Interruptions.Count != 4 {
+		t.Error("Expected CalculateStats to include interruption statistics")
+	}
+}
+
 func TestDateFilteringFunctions(t *testing.T) {
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, now.Location())
@@ -329,3 +365,29 @@ func findInString(haystack, needle string) bool {
 	}
 	return false
 }
+
+func TestPeriodHeadings(t *testing.T) {
+	now := time.Date(2025, 7, 9, 15, 0, 0, 0, time.Local)
+	period := func(expr string) DateRange {
+		dates, err := ParseDateRange("", "", expr, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dates
+	}
+	for _, tt := range []struct {
+		dates          DateRange
+		entries, stats string
+	}{
+		{DateRange{}, "", "All Time"},
+		{period("today"), "Today's sessions", "Today"},
+		{period("week"), "This week's sessions", "This Week"},
+		{period("month"), "This month's sessions", "This Month"},
+		{period("2025-01"), "January 2025 sessions", "January 2025"},
+		{period("2025-01-01..2025-01-10"), "Sessions from Jan 1 - Jan 10, 2025", "Jan 1 - Jan 10, 2025"},
+	} {
+		if entries, stats := periodHeadings(tt.dates, now); entries != tt.entries || stats != tt.stats {
+			t.Errorf("periodHeadings(%v) = %q, %q, want %q, %q", tt.dates, entries, stats, tt.entries, tt.stats)
+		}
+	}
+}
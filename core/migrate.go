@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"os"
+)
+
+// allStatuses selects entries of every status, including any cancelled ones
+var allStatuses = StatusFilter{Include: Statuses}
+
+// StoreCopy summarises a copy between storage backends
+type StoreCopy struct {
+	Copied  int
+	Skipped int
+	Session bool
+}
+
+// CopyStore copies the log and the active session from one store to another.
+// Entries the destination already has, by ID, are skipped, so an interrupted
+// copy can simply be run again. The source is left untouched.
+func CopyStore(from, to Store) (StoreCopy, error) {
+	var result StoreCopy
+
+	seen := make(map[string]bool)
+	for entry, err := range to.Entries(LogQuery{Statuses: allStatuses}) {
+		if err != nil {
+			return result, err
+		}
+		seen[entry.ID] = true
+	}
+
+	// Append oldest first, so files and tables fill up in order
+	for entry, err := range from.Entries(LogQuery{Statuses: allStatuses, Order: OldestFirst}) {
+		if err != nil {
+			return result, err
+		}
+		if seen[entry.ID] {
+			result.Skipped++
+			continue
+		}
+		if err := to.Append(entry); err != nil {
+			return result, err
+		}
+		seen[entry.ID] = true
+		result.Copied++
+	}
+
+	// The destination's active session should match the source's, even if
+	// that means there is none
+	session, err := from.GetSession()
+	switch {
+	case err == nil:
+		if err := to.PutSession(session); err != nil {
+			return result, err
+		}
+		result.Session = true
+	case errors.Is(err, os.ErrNotExist):
+		if err := to.DeleteSession(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, err
+		}
+	default:
+		return result, err
+	}
+	return result, nil
+}
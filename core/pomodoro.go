@@ -0,0 +1,229 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pomodoro phases
+const (
+	PhaseWork  = "work"
+	PhaseBreak = "break"
+)
+
+const defaultPomodoroRounds = 4
+
+// Pomodoro holds the interval plan and progress of a pomodoro session
+type Pomodoro struct {
+	Work       time.Duration `json:"work"`
+	Break      time.Duration `json:"break"`
+	Rounds     int           `json:"rounds"`
+	CycleID    string        `json:"cycle_id"`
+	Round      int           `json:"round"`
+	Phase      string        `json:"phase"`
+	PhaseStart time.Time     `json:"phase_start"`
+	// PhasePaused is the session's TotalPaused at the start of the current phase,
+	// so pauses taken during the phase can be told apart from earlier ones.
+	PhasePaused time.Duration `json:"phase_paused,omitempty"`
+	WorkDone    time.Duration `json:"work_done,omitempty"`
+}
+
+// PhaseChange describes a transition into a new pomodoro phase
+type PhaseChange struct {
+	Phase string
+	Round int
+	At    time.Time
+}
+
+var pomodoroSpecPattern = regexp.MustCompile(`^([0-9.hmsunµ]+)/([0-9.hmsunµ]+)(?:\s*x(\d+))?$`)
+
+// ParsePomodoroSpec parses a "WORK/BREAK xN" specification such as "25m/5m x4".
+// The round count is optional and defaults to 4.
+func ParsePomodoroSpec(spec string) (Pomodoro, error) {
+	var p Pomodoro
+	match := pomodoroSpecPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(spec)))
+	if match == nil {
+		return p, fmt.Errorf("invalid pomodoro spec %q (expected e.g. '25m/5m x4')", spec)
+	}
+
+	work, err := time.ParseDuration(match[1])
+	if err != nil || work <= 0 {
+		return p, fmt.Errorf("invalid work duration %q", match[1])
+	}
+	brk, err := time.ParseDuration(match[2])
+	if err != nil || brk < 0 {
+		return p, fmt.Errorf("invalid break duration %q", match[2])
+	}
+
+	rounds := defaultPomodoroRounds
+	if match[3] != "" {
+		rounds, err = strconv.Atoi(match[3])
+		if err != nil || rounds < 1 {
+			return p, fmt.Errorf("invalid round count %q", match[3])
+		}
+	}
+
+	return Pomodoro{Work: work, Break: brk, Rounds: rounds}, nil
+}
+
+// StartPomodoro returns the progress state for a pomodoro plan beginning at startTime
+func StartPomodoro(plan Pomodoro, startTime time.Time) *Pomodoro {
+	plan.CycleID = startTime.Format("20060102-150405")
+	plan.Round = 1
+	plan.Phase = PhaseWork
+	plan.PhaseStart = startTime
+	plan.PhasePaused = 0
+	plan.WorkDone = 0
+	return &plan
+}
+
+// phaseLength returns the planned length of the current phase
+func (p *Pomodoro) phaseLength() time.Duration {
+	if p.Phase == PhaseBreak {
+		return p.Break
+	}
+	return p.Work
+}
+
+// PhaseRemaining returns how much of the current phase is left at the given time.
+// Time spent paused does not count towards the phase.
+func PhaseRemaining(session Session, now time.Time) time.Duration {
+	p := session.Pomodoro
+	if p == nil {
+		return 0
+	}
+	if session.IsPaused {
+		now = session.PausedAt
+	}
+	elapsed := now.Sub(p.PhaseStart) - (session.TotalPaused - p.PhasePaused)
+	remaining := p.phaseLength() - elapsed
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// AdvancePomodoro logs every phase of the session that has finished by now and
+// moves the session into the phase that is current. It reports the phase changes
+// that happened and whether the whole cycle is complete. The caller is
+// responsible for persisting the session afterwards.
+func AdvancePomodoro(session *Session, now time.Time) ([]PhaseChange, bool, error) {
+	return advancePomodoro(session, now, StatusCompleted)
+}
+
+// advancePomodoro is AdvancePomodoro logging the finished phases with a status
+func advancePomodoro(session *Session, now time.Time, status string) ([]PhaseChange, bool, error) {
+	p := session.Pomodoro
+	if p == nil {
+		return nil, false, nil
+	}
+	if session.IsPaused {
+		now = session.PausedAt
+	}
+
+	var changes []PhaseChange
+	for {
+		phasePaused := session.TotalPaused - p.PhasePaused
+		phaseEnd := p.PhaseStart.Add(p.phaseLength() + phasePaused)
+		if phaseEnd.After(now) {
+			return changes, false, nil
+		}
+
+		if err := logPomodoroPhase(session, phaseEnd, p.phaseLength(), phasePaused, status); err != nil {
+			return changes, false, err
+		}
+
+		if p.Phase == PhaseWork && p.Round >= p.Rounds {
+			return changes, true, nil
+		}
+
+		if p.Phase == PhaseWork {
+			p.Phase = PhaseBreak
+		} else {
+			p.Phase = PhaseWork
+			p.Round++
+		}
+		p.PhaseStart = phaseEnd
+		p.PhasePaused = session.TotalPaused
+		changes = append(changes, PhaseChange{Phase: p.Phase, Round: p.Round, At: phaseEnd})
+	}
+}
+
+// FinishPomodoro logs the partially completed current phase when a pomodoro
+// session is ended early.
+func FinishPomodoro(session *Session, endTime time.Time) error {
+	return finishPomodoro(session, endTime, StatusCompleted)
+}
+
+// finishPomodoro is FinishPomodoro logging the phase with a status
+func finishPomodoro(session *Session, endTime time.Time, status string) error {
+	p := session.Pomodoro
+	if p == nil {
+		return nil
+	}
+	phasePaused := session.TotalPaused - p.PhasePaused
+	duration := endTime.Sub(p.PhaseStart) - phasePaused
+	if duration <= 0 {
+		return nil
+	}
+	return logPomodoroPhase(session, endTime, duration, phasePaused, status)
+}
+
+// logPomodoroPhase records a finished phase. Work phases go to the regular
+// session log, breaks are kept in a separate monthly break log.
+func logPomodoroPhase(session *Session, endTime time.Time, duration, paused time.Duration, status string) error {
+	p := session.Pomodoro
+	entry := LogEntry{
+		Status:      status,
+		Tag:         session.Tag,
+		Project:     session.Project,
+		Labels:      session.Labels,
+		Git:         session.Git,
+		StartTime:   p.PhaseStart,
+		EndTime:     endTime,
+		Duration:    duration,
+		TotalPaused: paused,
+		CycleID:     p.CycleID,
+		Phase:       p.Phase,
+		Round:       p.Round,
+		Pauses:      PausesBetween(session.Pauses, p.PhaseStart, endTime),
+	}
+
+	if p.Phase == PhaseBreak {
+		return LogBreak(entry)
+	}
+	addCommits(&entry)
+	if err := LogSession(entry); err != nil {
+		return err
+	}
+	p.WorkDone += duration
+	return nil
+}
+
+// GetBreakLogPath returns the path to the pomodoro break log file for a specific month
+func GetBreakLogPath(date time.Time) (string, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	filename := fmt.Sprintf("%s_breaks.jsonl", date.Format("200601"))
+	return filepath.Join(logDir, filename), nil
+}
+
+// LogBreak appends a completed pomodoro break to the appropriate monthly break
+// log, which in sync mode is this device's own
+func LogBreak(entry LogEntry) error {
+	logPath, err := GetBreakLogPath(entry.EndTime)
+	if err != nil {
+		return err
+	}
+	if device := syncDevice(); device != "" {
+		logPath = deviceLogPath(logPath, device)
+	}
+	entry.SchemaVersion = CurrentSchemaVersion
+	return appendLogEntry(logPath, entry)
+}
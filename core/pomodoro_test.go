@@ -0,0 +1,163 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePomodoroSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Pomodoro
+		wantErr bool
+	}{
+		{
+			name: "full spec",
+			spec: "25m/5m x4",
+			want: Pomodoro{Work: 25 * time.Minute, Break: 5 * time.Minute, Rounds: 4},
+		},
+		{
+			name: "no space before rounds",
+			spec: "50m/10mx2",
+			want: Pomodoro{Work: 50 * time.Minute, Break: 10 * time.Minute, Rounds: 2},
+		},
+		{
+			name: "default rounds",
+			spec: "1h/15m",
+			want: Pomodoro{Work: time.Hour, Break: 15 * time.Minute, Rounds: defaultPomodoroRounds},
+		},
+		{name: "missing break", spec: "25m", wantErr: true},
+		{name: "bad duration", spec: "abc/5m", wantErr: true},
+		{name: "zero work", spec: "0m/5m", wantErr: true},
+		{name: "zero rounds", spec: "25m/5m x0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePomodoroSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePomodoroSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePomodoroSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvancePomodoro(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	plan := Pomodoro{Work: 25 * time.Minute, Break: 5 * time.Minute, Rounds: 2}
+	session := Session{Tag: "sprint", StartTime: start, Pomodoro: StartPomodoro(plan, start)}
+
+	// Still inside the first work phase
+	changes, complete, err := AdvancePomodoro(&session, start.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("AdvancePomodoro() error = %v", err)
+	}
+	if len(changes) != 0 || complete {
+		t.Fatalf("expected no phase change, got %v (complete=%v)", changes, complete)
+	}
+	if remaining := PhaseRemaining(session, start.Add(10*time.Minute)); remaining != 15*time.Minute {
+		t.Errorf("PhaseRemaining() = %v, want 15m", remaining)
+	}
+
+	// Jump into the second work phase: work -> break -> work
+	changes, complete, err = AdvancePomodoro(&session, start.Add(32*time.Minute))
+	if err != nil {
+		t.Fatalf("AdvancePomodoro() error = %v", err)
+	}
+	if complete {
+		t.Fatal("cycle should not be complete yet")
+	}
+	if len(changes) != 2 || changes[0].Phase != PhaseBreak || changes[1].Phase != PhaseWork || changes[1].Round != 2 {
+		t.Fatalf("unexpected phase changes: %+v", changes)
+	}
+	if !session.Pomodoro.PhaseStart.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("PhaseStart = %v, want %v", session.Pomodoro.PhaseStart, start.Add(30*time.Minute))
+	}
+
+	// A pause during the phase pushes its end back
+	session.TotalPaused += 10 * time.Minute
+	_, complete, err = AdvancePomodoro(&session, start.Add(60*time.Minute))
+	if err != nil || complete {
+		t.Fatalf("expected phase to still be running after pause, complete=%v err=%v", complete, err)
+	}
+
+	changes, complete, err = AdvancePomodoro(&session, start.Add(65*time.Minute))
+	if err != nil {
+		t.Fatalf("AdvancePomodoro() error = %v", err)
+	}
+	if !complete || len(changes) != 0 {
+		t.Fatalf("expected cycle to complete, got changes=%v complete=%v", changes, complete)
+	}
+	if session.Pomodoro.WorkDone != 50*time.Minute {
+		t.Errorf("WorkDone = %v, want 50m", session.Pomodoro.WorkDone)
+	}
+
+	// Work phases are logged individually and linked to the cycle
+	reader, err := NewLogReader()
+	if err != nil {
+		t.Fatalf("NewLogReader() error = %v", err)
+	}
+	entries, err := reader.ReadAllEntries()
+	if err != nil {
+		t.Fatalf("ReadAllEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 work entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.CycleID != session.Pomodoro.CycleID || entry.Phase != PhaseWork {
+			t.Errorf("entry not linked to cycle: %+v", entry)
+		}
+		if entry.Duration != 25*time.Minute {
+			t.Errorf("entry duration = %v, want 25m", entry.Duration)
+		}
+	}
+	if entries[0].TotalPaused != 10*time.Minute {
+		t.Errorf("second round paused = %v, want 10m", entries[0].TotalPaused)
+	}
+
+	// Breaks are kept out of the session log
+	breakPath, err := GetBreakLogPath(start)
+	if err != nil {
+		t.Fatalf("GetBreakLogPath() error = %v", err)
+	}
+	breaks, _, err := readLogFile(breakPath)
+	if err != nil {
+		t.Fatalf("failed to read break log: %v", err)
+	}
+	if len(breaks) != 1 || breaks[0].Phase != PhaseBreak || breaks[0].Duration != 5*time.Minute {
+		t.Errorf("unexpected break log: %+v", breaks)
+	}
+}
+
+func TestFinishPomodoro(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	plan := Pomodoro{Work: 25 * time.Minute, Break: 5 * time.Minute, Rounds: 4}
+	session := Session{Tag: "sprint", StartTime: start, Pomodoro: StartPomodoro(plan, start)}
+
+	if err := FinishPomodoro(&session, start.Add(12*time.Minute)); err != nil {
+		t.Fatalf("FinishPomodoro() error = %v", err)
+	}
+	if session.Pomodoro.WorkDone != 12*time.Minute {
+		t.Errorf("WorkDone = %v, want 12m", session.Pomodoro.WorkDone)
+	}
+
+	reader, _ := NewLogReader()
+	entries, err := reader.ReadAllEntries()
+	if err != nil {
+		t.Fatalf("ReadAllEntries() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Duration != 12*time.Minute || entries[0].Round != 1 {
+		t.Errorf("unexpected entries after finishing early: %+v", entries)
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// projectPathPattern matches a leading word that names a project: lower case,
+// with at least two characters before the first slash, so words like "I/O",
+// "A/B" or "CI/CD" stay part of the description
+var projectPathPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]+/[a-z0-9_./-]*$`)
+
+// ParseTag splits a tag into its description, project and labels. A leading
+// lower-case project path is the project ("acme/web"; a trailing slash marks
+// a top-level project, as in "acme/"), and words starting with # are labels.
+func ParseTag(tag string) (description, project string, labels []string) {
+	var words []string
+	for i, word := range strings.Fields(tag) {
+		switch {
+		case i == 0 && projectPathPattern.MatchString(word):
+			project = NormalizeProject(word)
+		case strings.HasPrefix(word, "#") && len(word) > 1:
+			labels = addLabel(labels, word)
+		default:
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " "), project, labels
+}
+
+// ResolveTag combines the project and labels parsed from a tag with those given
+// as flags. A project flag wins over one in the tag, and labels from both are
+// kept. A tag that was nothing but a project is described by the project.
+func ResolveTag(tag, project string, labels []string) (string, string, []string) {
+	description, tagProject, tagLabels := ParseTag(tag)
+	if project = NormalizeProject(project); project == "" {
+		project = tagProject
+	}
+	for _, label := range labels {
+		tagLabels = addLabel(tagLabels, label)
+	}
+	if description == "" {
+		description = project
+	}
+	return description, project, tagLabels
+}
+
+// NormalizeProject tidies a project path, dropping empty segments and
+// surrounding whitespace
+func NormalizeProject(project string) string {
+	var segments []string
+	for _, segment := range strings.Split(project, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// addLabel appends a label without its leading # unless it is already present.
+// Labels are compared case-insensitively and stored in lower case.
+func addLabel(labels []string, label string) []string {
+	label = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(label), "#"))
+	if label == "" || slices.Contains(labels, label) {
+		return labels
+	}
+	return append(labels, label)
+}
+
+// InProject reports whether project is parent or one of its subprojects
+func InProject(project, parent string) bool {
+	parent = NormalizeProject(parent)
+	return parent == "" || project == parent || strings.HasPrefix(project, parent+"/")
+}
+
+// ProjectFilter selects log entries by project and labels
+type ProjectFilter struct {
+	// Project selects entries in this project or any of its subprojects
+	Project string
+	// Labels selects entries carrying all of these labels
+	Labels []string
+}
+
+// Matches reports whether an entry passes the filter
+func (f ProjectFilter) Matches(entry LogEntry) bool {
+	if !InProject(entry.Project, f.Project) {
+		return false
+	}
+	for _, label := range f.Labels {
+		label = strings.ToLower(strings.TrimPrefix(label, "#"))
+		if !slices.Contains(entry.Labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByProject returns the entries that pass the filter
+func FilterByProject(entries []LogEntry, filter ProjectFilter) []LogEntry {
+	if filter.Project == "" && len(filter.Labels) == 0 {
+		return entries
+	}
+	filtered := []LogEntry{}
+	for _, entry := range entries {
+		if filter.Matches(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// ProjectStat is the time spent on a project, including its subprojects
+type ProjectStat struct {
+	Project  string
+	Depth    int
+	Duration time.Duration
+	Count    int
+}
+
+// Name returns the last segment of the project path
+func (p ProjectStat) Name() string {
+	return p.Project[strings.LastIndex(p.Project, "/")+1:]
+}
+
+// CalculateProjectRollup totals time per project at every level of the
+// hierarchy, so "acme" includes time logged to "acme/web" and "acme/api".
+// Projects are returned in tree order: each parent before its subprojects.
+func CalculateProjectRollup(entries []LogEntry) []ProjectStat {
+	totals := make(map[string]*ProjectStat)
+	for _, entry := range entries {
+		if entry.Project == "" {
+			continue
+		}
+		segments := strings.Split(entry.Project, "/")
+		for depth := range segments {
+			path := strings.Join(segments[:depth+1], "/")
+			stat, ok := totals[path]
+			if !ok {
+				stat = &ProjectStat{Project: path, Depth: depth}
+				totals[path] = stat
+			}
+			stat.Duration += entry.Duration
+			stat.Count++
+		}
+	}
+
+	return sortProjectStats(totals)
+}
+
+// sortProjectStats lists project totals in tree order
+func sortProjectStats(totals map[string]*ProjectStat) []ProjectStat {
+	stats := make([]ProjectStat, 0, len(totals))
+	for _, stat := range totals {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		// Compare segment by segment so "a/b" sorts right after "a"
+		return slices.Compare(strings.Split(stats[i].Project, "/"), strings.Split(stats[j].Project, "/")) < 0
+	})
+	return stats
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag             string
+		wantDescription string
+		wantProject     string
+		wantLabels      []string
+	}{
+		{"Deep Work", "Deep Work", "", nil},
+		{"acme/web Fix login #bug #Urgent", "Fix login", "acme/web", []string{"bug", "urgent"}},
+		{"acme/ Planning", "Planning", "acme", nil},
+		{"Review #bug #bug", "Review", "", []string{"bug"}},
+		{"Fix acme/web", "Fix acme/web", "", nil},
+		{"I/O tuning", "I/O tuning", "", nil},
+		{"A/B test copy", "A/B test copy", "", nil},
+		{"CI/CD fixes", "CI/CD fixes", "", nil},
+		{"i/o tuning", "i/o tuning", "", nil},
+		{"client-2/web.app Deploy", "Deploy", "client-2/web.app", nil},
+	}
+
+	for _, tt := range tests {
+		description, project, labels := ParseTag(tt.tag)
+		if description != tt.wantDescription || project != tt.wantProject || !slices.Equal(labels, tt.wantLabels) {
+			t.Errorf("ParseTag(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.tag, description, project, labels, tt.wantDescription, tt.wantProject, tt.wantLabels)
+		}
+	}
+}
+
+func TestResolveTag(t *testing.T) {
+	tag, project, labels := ResolveTag("acme/web Fix login #bug", "acme/api/", []string{"#review", "bug"})
+	if tag != "Fix login" || project != "acme/api" || !slices.Equal(labels, []string{"bug", "review"}) {
+		t.Errorf("ResolveTag() = %q, %q, %v", tag, project, labels)
+	}
+
+	tag, project, _ = ResolveTag("acme/web", "", nil)
+	if tag != "acme/web" || project != "acme/web" {
+		t.Errorf("ResolveTag() for a bare project = %q, %q", tag, project)
+	}
+}
+
+func TestProjectFilter(t *testing.T) {
+	entries := []LogEntry{
+		{Tag: "A", Project: "acme/web", Labels: []string{"bug"}},
+		{Tag: "B", Project: "acme/api", Labels: []string{"bug", "urgent"}},
+		{Tag: "C", Project: "acmecorp"},
+		{Tag: "D"},
+	}
+
+	tests := []struct {
+		filter ProjectFilter
+		want   []string
+	}{
+		{ProjectFilter{}, []string{"A", "B", "C", "D"}},
+		{ProjectFilter{Project: "acme"}, []string{"A", "B"}},
+		{ProjectFilter{Project: "acme/web/"}, []string{"A"}},
+		{ProjectFilter{Labels: []string{"#Bug"}}, []string{"A", "B"}},
+		{ProjectFilter{Labels: []string{"bug", "urgent"}}, []string{"B"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, entry := range FilterByProject(entries, tt.filter) {
+			got = append(got, entry.Tag)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FilterByProject(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateProjectRollup(t *testing.T) {
+	entries := []LogEntry{
+		{Project: "acme/web", Duration: time.Hour},
+		{Project: "acme/api", Duration: 30 * time.Minute},
+		{Project: "acme", Duration: 15 * time.Minute},
+		{Project: "home", Duration: 20 * time.Minute},
+		{Duration: time.Hour},
+	}
+
+	want := []ProjectStat{
+		{Project: "acme", Depth: 0, Duration: 105 * time.Minute, Count: 3},
+		{Project: "acme/api", Depth: 1, Duration: 30 * time.Minute, Count: 1},
+		{Project: "acme/web", Depth: 1, Duration: time.Hour, Count: 1},
+		{Project: "home", Depth: 0, Duration: 20 * time.Minute, Count: 1},
+	}
+
+	got := CalculateProjectRollup(entries)
+	if !slices.Equal(got, want) {
+		t.Errorf("CalculateProjectRollup() = %+v, want %+v", got, want)
+	}
+	if got[2].Name() != "web" {
+		t.Errorf("Name() = %q, want %q", got[2].Name(), "web")
+	}
+}
@@ -0,0 +1,286 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CurrentSchemaVersion is the version of the log entry format written by
+// this version of flow:
+//
+//	1: entries without a schema_version. They may lack an ID or a status, and
+//	   abandoned sessions are marked with an [ABANDONED] tag suffix.
+//	2: every entry carries its ID, status and schema_version.
+const CurrentSchemaVersion = 2
+
+// schemaUpgrades[v-1] upgrades an entry from version v to v+1. line is the
+// entry as it is stored, for upgrades that derive values from it.
+var schemaUpgrades = []func(entry *LogEntry, line string){
+	upgradeToV2,
+}
+
+// upgradeToV2 gives entries a stable ID and a status
+func upgradeToV2(entry *LogEntry, line string) {
+	if entry.ID == "" {
+		entry.ID = legacyID(*entry, line)
+	}
+	normalizeStatus(entry)
+}
+
+// storedVersion returns the schema version an entry was written with
+func storedVersion(entry LogEntry) int {
+	if entry.SchemaVersion == 0 {
+		return 1
+	}
+	return entry.SchemaVersion
+}
+
+// upgradeEntry brings an entry read from storage up to the current schema.
+// Entries written by a newer version of flow are left as they are.
+func upgradeEntry(entry *LogEntry, line string) {
+	for version := storedVersion(*entry); version < CurrentSchemaVersion; version++ {
+		schemaUpgrades[version-1](entry, line)
+	}
+	if entry.SchemaVersion < CurrentSchemaVersion {
+		entry.SchemaVersion = CurrentSchemaVersion
+	}
+}
+
+// upgradeLine rewrites a stored entry in the current schema, returning the
+// version it was stored in. Entries already at the current version or newer
+// are returned unchanged.
+func upgradeLine(line string) (string, int, error) {
+	var stored LogEntry
+	if err := json.Unmarshal([]byte(line), &stored); err != nil {
+		return line, 0, err
+	}
+	version := storedVersion(stored)
+	if version >= CurrentSchemaVersion {
+		return line, version, nil
+	}
+	entry, err := parseLogLine(line)
+	if err != nil {
+		return line, version, err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return line, version, err
+	}
+	return string(data), version, nil
+}
+
+// SchemaFile reports the schema versions found in one log file or table
+type SchemaFile struct {
+	Name      string
+	Entries   int
+	Upgraded  int
+	Malformed int
+	Versions  map[int]int
+}
+
+// record counts an entry stored in version, upgraded or not
+func (f *SchemaFile) record(version int, upgraded bool) {
+	if f.Versions == nil {
+		f.Versions = make(map[int]int)
+	}
+	f.Entries++
+	f.Versions[version]++
+	if upgraded {
+		f.Upgraded++
+	}
+}
+
+// SchemaReport summarises a schema migration
+type SchemaReport struct {
+	Files  []SchemaFile
+	Backup string
+}
+
+// Upgraded returns the number of entries that were, or would be, rewritten
+func (r SchemaReport) Upgraded() int {
+	total := 0
+	for _, file := range r.Files {
+		total += file.Upgraded
+	}
+	return total
+}
+
+// MigrateSchema rewrites every stored entry in the current schema. Before
+// anything is rewritten the data directory is backed up, and the backup's
+// location is reported. With dryRun nothing is written and the report says
+// what would change.
+func MigrateSchema(dryRun bool) (SchemaReport, error) {
+	var report SchemaReport
+
+	// Find out what needs upgrading first, so data that is already current
+	// isn't backed up for nothing
+	files, err := upgradeSchema(true)
+	report.Files = files
+	if err != nil || dryRun || report.Upgraded() == 0 {
+		return report, err
+	}
+
+	if report.Backup, err = BackupDataDir("migrate"); err != nil {
+		return report, fmt.Errorf("failed to back up data before migrating: %w", err)
+	}
+	report.Files, err = upgradeSchema(false)
+	return report, err
+}
+
+// upgradeSchema upgrades the store's entries and the pomodoro break logs,
+// which are kept as files whichever backend is in use
+func upgradeSchema(dryRun bool) ([]SchemaFile, error) {
+	var files []SchemaFile
+	err := withStore(func(store Store) error {
+		var err error
+		files, err = store.UpgradeSchema(dryRun)
+		return err
+	})
+	if err != nil {
+		return files, err
+	}
+
+	logDir, err := GetLogDir()
+	if err != nil {
+		return files, err
+	}
+	breakLogs, err := deviceFiles(filepath.Join(logDir, "*_breaks.jsonl"))
+	if err != nil {
+		return files, err
+	}
+	device := syncDevice()
+	for _, path := range breakLogs {
+		if otherDevices(path, device) {
+			continue
+		}
+		file, err := upgradeLogFile(path, dryRun)
+		files = append(files, file)
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// upgradeLogFile rewrites a JSONL log file in the current schema. Malformed
+// lines are kept as they are.
+func upgradeLogFile(path string, dryRun bool) (SchemaFile, error) {
+	file := SchemaFile{Name: filepath.Base(path)}
+	upgrade := func(line string) (string, bool) {
+		if strings.TrimSpace(line) == "" {
+			return line, true
+		}
+		newLine, version, err := upgradeLine(line)
+		if err != nil {
+			file.Malformed++
+			return line, true
+		}
+		file.record(version, newLine != line)
+		return newLine, true
+	}
+
+	if dryRun {
+		return file, forEachLine(path, func(line string) { upgrade(line) })
+	}
+	_, err := rewriteLines(path, upgrade)
+	return file, err
+}
+
+// GetDataDir returns the directory holding the logs, the database and backups
+func GetDataDir() (string, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(logDir), nil
+}
+
+// BackupDataDir copies the log directory, the SQLite database and the session
+// file into a new directory under backups/ in the data directory, named after
+// the reason and the current time. It returns the backup's path.
+func BackupDataDir(reason string) (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	backupDir := filepath.Join(dataDir, "backups", reason+"-"+time.Now().Format("20060102-150405"))
+	if _, err := os.Stat(backupDir); err == nil {
+		return "", fmt.Errorf("backup %s already exists", backupDir)
+	}
+
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	if err := copyDir(logDir, filepath.Join(backupDir, "logs")); err != nil {
+		return "", err
+	}
+
+	dbPath, err := GetDatabasePath()
+	if err != nil {
+		return "", err
+	}
+	sessionPath, err := GetSessionPath()
+	if err != nil {
+		return "", err
+	}
+	// The database's write-ahead log holds changes not yet in flow.db
+	for _, path := range []string{dbPath, dbPath + "-wal", sessionPath} {
+		if err := copyFile(path, filepath.Join(backupDir, filepath.Base(path))); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return backupDir, nil
+}
+
+// copyDir copies the regular files in a directory, skipping lock files.
+// A missing directory is not an error.
+func copyDir(from, to string) error {
+	entries, err := os.ReadDir(from)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		if err := copyFile(filepath.Join(from, entry.Name()), filepath.Join(to, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies a file, creating the destination's directory if needed
+func copyFile(from, to string) (err error) {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	if err := ensureDir(to); err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogLineUpgradesLegacyEntries(t *testing.T) {
+	line := `{"tag":"Deep work [ABANDONED]","start_time":"2025-01-02T09:00:00Z","end_time":"2025-01-02T10:00:00Z","duration":3600000000000}`
+	entry, err := parseLogLine(line)
+	if err != nil {
+		t.Fatalf("parseLogLine() error = %v", err)
+	}
+	if entry.SchemaVersion != CurrentSchemaVersion || entry.ID == "" || entry.Tag != "Deep work" || entry.Status != StatusAbandoned {
+		t.Errorf("parseLogLine() = %+v, want an upgraded entry", entry)
+	}
+
+	// Entries from a newer version are read as they are
+	future := `{"id":"X","tag":"Later [ABANDONED]","status":"completed","schema_version":99}`
+	entry, err = parseLogLine(future)
+	if err != nil || entry.SchemaVersion != 99 || entry.Tag != "Later [ABANDONED]" {
+		t.Errorf("parseLogLine(newer) = %+v, %v", entry, err)
+	}
+}
+
+func TestMigrateSchema(t *testing.T) {
+	for _, backend := range StorageBackends {
+		t.Run(backend, func(t *testing.T) {
+			dataDir := t.TempDir()
+			configDir := t.TempDir()
+			t.Setenv("XDG_DATA_HOME", dataDir)
+			t.Setenv("XDG_CONFIG_HOME", configDir)
+			t.Setenv("FLOW_LOG_PATH", "")
+			t.Setenv("FLOW_SESSION_PATH", "")
+			if err := os.MkdirAll(filepath.Join(configDir, "flow"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(configDir, "flow", "config.yml"), []byte("storage: "+backend+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// A legacy entry stored as it was written by an older version
+			legacy := `{"tag":"Old [ABANDONED]","start_time":"2025-01-02T09:00:00Z","end_time":"2025-01-02T10:00:00Z","duration":3600000000000}`
+			logDir := filepath.Join(dataDir, "flow", "logs")
+			switch backend {
+			case StorageJSONL:
+				if err := os.MkdirAll(logDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(logDir, "202501_sessions.jsonl"), []byte(legacy+"\nnot json\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			case StorageSQLite:
+				store, err := NewSQLiteStore()
+				if err != nil {
+					t.Fatal(err)
+				}
+				start := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+				_, err = store.(*sqliteStore).db.Exec(`INSERT INTO entries (id, tag, start_time, end_time, data) VALUES (?, ?, ?, ?, ?)`,
+					legacyID(LogEntry{StartTime: start}, legacy), "Old [ABANDONED]", start.UnixNano(), start.Add(time.Hour).UnixNano(), legacy)
+				if closeErr := store.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			now := time.Now()
+			if err := LogSession(LogEntry{Tag: "Current", StartTime: now.Add(-time.Hour), EndTime: now, Duration: time.Hour}); err != nil {
+				t.Fatal(err)
+			}
+			before, err := GetRecentSessions(10)
+			if err != nil || len(before) != 2 {
+				t.Fatalf("GetRecentSessions() = %+v, %v", before, err)
+			}
+
+			report, err := MigrateSchema(true)
+			if err != nil {
+				t.Fatalf("MigrateSchema(dry run) error = %v", err)
+			}
+			if report.Upgraded() != 1 || report.Backup != "" {
+				t.Errorf("MigrateSchema(dry run) = %+v, want 1 entry to upgrade and no backup", report)
+			}
+			if again, _ := MigrateSchema(true); again.Upgraded() != 1 {
+				t.Error("Expected a dry run to leave the data unchanged")
+			}
+
+			report, err = MigrateSchema(false)
+			if err != nil {
+				t.Fatalf("MigrateSchema() error = %v", err)
+			}
+			if report.Upgraded() != 1 || report.Backup == "" {
+				t.Errorf("MigrateSchema() = %+v, want 1 entry upgraded and a backup", report)
+			}
+			if _, err := os.Stat(report.Backup); err != nil {
+				t.Errorf("Expected the backup to exist: %v", err)
+			}
+
+			after, err := GetRecentSessions(10)
+			if err != nil || len(after) != 2 {
+				t.Fatalf("GetRecentSessions() after migrating = %+v, %v", after, err)
+			}
+			for i := range after {
+				if after[i].ID != before[i].ID || after[i].Tag != before[i].Tag || after[i].Status != before[i].Status {
+					t.Errorf("Entry %d changed from %+v to %+v", i, before[i], after[i])
+				}
+			}
+
+			if report, _ := MigrateSchema(false); report.Upgraded() != 0 || report.Backup != "" {
+				t.Errorf("MigrateSchema() again = %+v, want nothing to do", report)
+			}
+
+			if backend == StorageJSONL {
+				data, err := os.ReadFile(filepath.Join(logDir, "202501_sessions.jsonl"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(data), `"schema_version":2`) || !strings.Contains(string(data), "not json") {
+					t.Errorf("Migrated file = %s, want the entry upgraded and the malformed line kept", data)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,15 +17,55 @@ type Session struct {
 	PausedAt       time.Time     `json:"paused_at,omitempty"`
 	IsPaused       bool          `json:"is_paused"`
 	TotalPaused    time.Duration `json:"total_paused"`
+	Pomodoro       *Pomodoro     `json:"pomodoro,omitempty"`
+	Pauses         []Pause       `json:"pauses,omitempty"`
+	Preset         string        `json:"preset,omitempty"`
+	Project        string        `json:"project,omitempty"`
+	Labels         []string      `json:"labels,omitempty"`
+	LastActivity   time.Time     `json:"last_activity,omitempty"`
+	Git            *GitContext   `json:"git,omitempty"`
+	// Device is the device the session was started on
+	Device string `json:"device,omitempty"`
+	// Interrupted holds the sessions suspended by 'flow interrupt', outermost first
+	Interrupted []Session `json:"interrupted,omitempty"`
+	// PausedByInterrupt marks a held session that was running until it was
+	// interrupted, and so is resumed when the interruption ends
+	PausedByInterrupt bool `json:"paused_by_interrupt,omitempty"`
+}
+
+// Pause records a single interruption of a session
+type Pause struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Duration returns how long the pause lasted, or zero if it is still open
+func (p Pause) Duration() time.Duration {
+	if p.End.IsZero() {
+		return 0
+	}
+	return p.End.Sub(p.Start)
 }
 
 // LogEntry represents a completed session for logging
 type LogEntry struct {
-	Tag         string        `json:"tag"`
-	StartTime   time.Time     `json:"start_time"`
-	EndTime     time.Time     `json:"end_time"`
-	Duration    time.Duration `json:"duration"`
-	TotalPaused time.Duration `json:"total_paused,omitempty"`
+	ID            string        `json:"id,omitempty"`
+	Tag           string        `json:"tag"`
+	StartTime     time.Time     `json:"start_time"`
+	EndTime       time.Time     `json:"end_time"`
+	Duration      time.Duration `json:"duration"`
+	TotalPaused   time.Duration `json:"total_paused,omitempty"`
+	CycleID       string        `json:"cycle_id,omitempty"`
+	Phase         string        `json:"phase,omitempty"`
+	Round         int           `json:"round,omitempty"`
+	Pauses        []Pause       `json:"pauses,omitempty"`
+	Status        string        `json:"status,omitempty"`
+	Project       string        `json:"project,omitempty"`
+	Labels        []string      `json:"labels,omitempty"`
+	Git           *GitContext   `json:"git,omitempty"`
+	Commits       []Commit      `json:"commits,omitempty"`
+	SchemaVersion int           `json:"schema_version,omitempty"`
 }
 
 // Session file management
@@ -34,19 +75,28 @@ func GetSessionPath() (string, error) {
 		return path, nil
 	}
 
-	// 2. Check for XDG_DATA_HOME environment variable
+	// 2. In sync mode, each device keeps its own session in the data directory
+	if device := syncDevice(); device != "" {
+		dir, err := getDeviceSessionDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, device), nil
+	}
+
+	// 3. Check for XDG_DATA_HOME environment variable
 	if xdgDataHome := os.Getenv("XDG_DATA_HOME"); xdgDataHome != "" {
 		return filepath.Join(xdgDataHome, "flow", "session"), nil
 	}
 
-	// 3. Fallback to ~/.local/share/flow/session (default XDG)
+	// 4. Fallback to ~/.local/share/flow/session (default XDG)
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("could not get user home directory: %w", err)
 	}
 	xdgDefaultPath := filepath.Join(homeDir, ".local", "share", "flow", "session")
 
-	// 4. For backward compatibility, check if the old ~/.flow-session file exists
+	// 5. For backward compatibility, check if the old ~/.flow-session file exists
 	legacyPath := filepath.Join(homeDir, ".flow-session")
 	if _, err := os.Stat(legacyPath); err == nil {
 		return legacyPath, nil
@@ -95,52 +145,72 @@ func GetLogDir() (string, error) {
 	}
 }
 
+// SessionExists reports whether there is an active session
 func SessionExists() bool {
-	path, err := GetSessionPath()
-	if err != nil {
-		return false
-	}
-	_, err = os.Stat(path)
-	return err == nil
+	_, err := LoadSession()
+	// A session that can't be read still exists; loading it reports why
+	return !errors.Is(err, os.ErrNotExist)
 }
 
+// RemoveSession deletes the active session
+func RemoveSession() error {
+	return withStore(func(store Store) error {
+		return store.DeleteSession()
+	})
+}
+
+// LoadSession returns the active session
 func LoadSession() (Session, error) {
 	var session Session
-	path, err := GetSessionPath()
-	if err != nil {
-		return session, err
-	}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return session, err
-	}
-	err = json.Unmarshal(data, &session)
+	err := withStore(func(store Store) error {
+		var err error
+		session, err = store.GetSession()
+		return err
+	})
 	return session, err
 }
 
+// SaveSession makes session the active session, marking it with this device
+// if it isn't marked yet
 func SaveSession(session Session) error {
-	path, err := GetSessionPath()
-	if err != nil {
-		return err
+	if session.Device == "" {
+		session.Device = DeviceID()
 	}
-	// Ensure the directory exists
-	if err := ensureDir(path); err != nil {
+	return withStore(func(store Store) error {
+		return store.PutSession(session)
+	})
+}
+
+// LogSession appends a completed session to the log
+func LogSession(entry LogEntry) error {
+	if err := appendSession(entry); err != nil {
 		return err
 	}
-	data, err := json.Marshal(session)
-	if err != nil {
-		return err
+
+	// The session is logged; tidying up old files is best-effort
+	if err := applyRetentionDaily(entry.EndTime); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: applying the retention policy: %v\n", err)
 	}
-	return os.WriteFile(path, data, 0644)
+	return nil
 }
 
-// LogSession appends a completed session to the appropriate monthly log file
-func LogSession(entry LogEntry) error {
-	logPath, err := GetLogPath(entry.EndTime)
-	if err != nil {
-		return err
+// appendSession adds a session to the log without applying the retention
+// policy, as when backfilling old sessions
+func appendSession(entry LogEntry) error {
+	if entry.ID == "" {
+		entry.ID = NewID(entry.StartTime)
+	}
+	if entry.Status == "" {
+		entry.Status = StatusCompleted
 	}
+	entry.SchemaVersion = CurrentSchemaVersion
+	return withStore(func(store Store) error {
+		return store.Append(entry)
+	})
+}
 
+// appendLogEntry appends a single entry to a JSON Lines log file
+func appendLogEntry(logPath string, entry LogEntry) error {
 	// Ensure the directory exists
 	if err := ensureDir(logPath); err != nil {
 		return err
@@ -152,6 +222,12 @@ func LogSession(entry LogEntry) error {
 		return err
 	}
 
+	unlock, err := lockLogs()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Append to log file
 	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -169,6 +245,47 @@ func LogSession(entry LogEntry) error {
 	return err
 }
 
+// LogCompletedSession logs a session as completed at endTime and returns the
+// focus time that was recorded. Pomodoro sessions only log their current phase,
+// since earlier phases have already been logged as they finished.
+func LogCompletedSession(session *Session, endTime time.Time) (time.Duration, error) {
+	if session.Pomodoro != nil {
+		err := FinishPomodoro(session, endTime)
+		return session.Pomodoro.WorkDone, err
+	}
+
+	totalDuration := endTime.Sub(session.StartTime) - session.TotalPaused
+	if totalDuration < 0 {
+		totalDuration = 0
+	}
+
+	logEntry := LogEntry{
+		Tag:         session.Tag,
+		Project:     session.Project,
+		Labels:      session.Labels,
+		Git:         session.Git,
+		StartTime:   session.StartTime,
+		EndTime:     endTime,
+		Duration:    totalDuration,
+		TotalPaused: session.TotalPaused,
+		Pauses:      PausesBetween(session.Pauses, session.StartTime, endTime),
+	}
+	addCommits(&logEntry)
+	return totalDuration, LogSession(logEntry)
+}
+
+// PausesBetween returns the finished pauses that started within [start, end)
+func PausesBetween(pauses []Pause, start, end time.Time) []Pause {
+	var result []Pause
+	for _, p := range pauses {
+		if p.End.IsZero() || p.Start.Before(start) || !p.Start.Before(end) {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
 // ensureDir creates the directory for the given path if it doesn't already exist.
 func ensureDir(path string) error {
 	dir := filepath.Dir(path)
@@ -188,38 +305,43 @@ func IsSessionStale(session Session, threshold time.Duration) bool {
 	return time.Since(session.StartTime) > threshold
 }
 
-// CleanupStaleSession removes a stale session file and optionally logs it as abandoned
+// CleanupStaleSession removes a stale session file and optionally logs its full
+// runtime as abandoned, along with any sessions it interrupted.
 func CleanupStaleSession(session Session, logAsAbandoned bool) error {
-	if logAsAbandoned {
-		// Log the session as abandoned with a special tag
-		endTime := time.Now()
-		if session.IsPaused {
-			endTime = session.PausedAt
-		}
-
-		totalDuration := endTime.Sub(session.StartTime) - session.TotalPaused
-		if totalDuration < 0 {
-			totalDuration = 0 // Ensure non-negative duration
-		}
+	if !logAsAbandoned {
+		return RemoveSession()
+	}
+	end, _ := StaleEndTime(session, StalePolicyKeep, 0, time.Now())
+	return EndSessionAt(session, end, true)
+}
 
-		logEntry := LogEntry{
-			Tag:         session.Tag + " [ABANDONED]",
-			StartTime:   session.StartTime,
-			EndTime:     endTime,
-			Duration:    totalDuration,
-			TotalPaused: session.TotalPaused,
-		}
+// logAbandonedSession logs a session that was never ended, with a special tag
+func logAbandonedSession(session Session) error {
+	endTime := time.Now()
+	if session.IsPaused {
+		endTime = session.PausedAt
+	}
 
-		if err := LogSession(logEntry); err != nil {
-			return fmt.Errorf("failed to log abandoned session: %w", err)
-		}
+	totalDuration := endTime.Sub(session.StartTime) - session.TotalPaused
+	if totalDuration < 0 {
+		totalDuration = 0 // Ensure non-negative duration
 	}
 
-	// Remove the session file
-	sessionPath, err := GetSessionPath()
-	if err != nil {
-		return fmt.Errorf("failed to get session path: %w", err)
+	logEntry := LogEntry{
+		Tag:         session.Tag,
+		Project:     session.Project,
+		Labels:      session.Labels,
+		Git:         session.Git,
+		Status:      StatusAbandoned,
+		StartTime:   session.StartTime,
+		EndTime:     endTime,
+		Duration:    totalDuration,
+		TotalPaused: session.TotalPaused,
+		Pauses:      PausesBetween(session.Pauses, session.StartTime, endTime),
 	}
 
-	return os.Remove(sessionPath)
+	if err := LogSession(logEntry); err != nil {
+		return fmt.Errorf("failed to log abandoned session: %w", err)
+	}
+	return nil
 }
@@ -547,3 +547,54 @@ func TestIsSessionStale(t *testing.T) {
 		})
 	}
 }
+
+func TestPausesBetween(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	pauses := []Pause{
+		{Start: start.Add(-10 * time.Minute), End: start.Add(-5 * time.Minute), Reason: "before"},
+		{Start: start.Add(10 * time.Minute), End: start.Add(15 * time.Minute), Reason: "inside"},
+		{Start: start.Add(70 * time.Minute), End: start.Add(75 * time.Minute), Reason: "after"},
+		{Start: start.Add(30 * time.Minute), Reason: "open"},
+	}
+
+	got := PausesBetween(pauses, start, start.Add(time.Hour))
+	if len(got) != 1 || got[0].Reason != "inside" {
+		t.Errorf("PausesBetween() = %+v, want only the 'inside' pause", got)
+	}
+	if got[0].Duration() != 5*time.Minute {
+		t.Errorf("Pause.Duration() = %v, want 5m", got[0].Duration())
+	}
+	if (Pause{Start: start}).Duration() != 0 {
+		t.Error("Expected an open pause to have zero duration")
+	}
+}
+
+func TestLogCompletedSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	session := Session{
+		Tag:         "focus",
+		StartTime:   start,
+		TotalPaused: 10 * time.Minute,
+		Pauses:      []Pause{{Start: start.Add(20 * time.Minute), End: start.Add(30 * time.Minute), Reason: "call"}},
+	}
+
+	duration, err := LogCompletedSession(&session, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("LogCompletedSession() error = %v", err)
+	}
+	if duration != 50*time.Minute {
+		t.Errorf("Expected 50m of focus time, got %v", duration)
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 logged entry, got %d", len(entries))
+	}
+	if entries[0].Duration != 50*time.Minute || len(entries[0].Pauses) != 1 {
+		t.Errorf("Unexpected logged entry: %+v", entries[0])
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"time"
+)
+
+// InterruptSession suspends the active session and returns a new session for
+// the interruption, carrying the suspended one on its stack. A running session
+// is paused, with the interruption's tag as the reason, so its paused time
+// covers the whole interruption; one already paused stays paused after it.
+func InterruptSession(session Session, tag string, now time.Time) Session {
+	stack := append([]Session(nil), session.Interrupted...)
+	session.Interrupted = nil
+	if !session.IsPaused {
+		session.IsPaused = true
+		session.PausedAt = now
+		session.Pauses = append(session.Pauses, Pause{Start: now, Reason: tag})
+		session.PausedByInterrupt = true
+	}
+
+	return Session{
+		Tag:         tag,
+		StartTime:   now,
+		Interrupted: append(stack, session),
+	}
+}
+
+// ResumeSession restarts a paused session's timer, closing its open pause
+func ResumeSession(session *Session, now time.Time) {
+	if !session.IsPaused {
+		return
+	}
+	session.TotalPaused += now.Sub(session.PausedAt)
+	if n := len(session.Pauses); n > 0 && session.Pauses[n-1].End.IsZero() {
+		session.Pauses[n-1].End = now
+	}
+	session.IsPaused = false
+	session.PausedAt = time.Time{}
+	if !session.LastActivity.IsZero() {
+		// Resuming is activity; don't count the pause as idle time
+		session.LastActivity = now
+	}
+}
+
+// CloseSession removes a session that has been logged or discarded. If it was
+// an interruption, the session it interrupted becomes the active session
+// again, resumed unless it was paused before the interruption, and is
+// returned; otherwise the result is nil.
+func CloseSession(session Session, now time.Time) (*Session, error) {
+	n := len(session.Interrupted)
+	if n == 0 {
+		return nil, RemoveSession()
+	}
+
+	parent := session.Interrupted[n-1]
+	parent.Interrupted = session.Interrupted[:n-1]
+	if parent.PausedByInterrupt {
+		ResumeSession(&parent, now)
+		parent.PausedByInterrupt = false
+	}
+	if err := SaveSession(parent); err != nil {
+		return nil, err
+	}
+	return &parent, nil
+}
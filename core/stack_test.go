@@ -0,0 +1,138 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInterruptAndReturn(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	feature := Session{Tag: "Feature", StartTime: start}
+
+	incident := InterruptSession(feature, "Incident", start.Add(time.Hour))
+	if incident.Tag != "Incident" || len(incident.Interrupted) != 1 {
+		t.Fatalf("Expected an interruption holding one session, got %+v", incident)
+	}
+	held := incident.Interrupted[0]
+	if !held.IsPaused || !held.PausedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the interrupted session to be paused at the interruption, got %+v", held)
+	}
+	if len(held.Pauses) != 1 || held.Pauses[0].Reason != "Incident" {
+		t.Errorf("Expected a pause with the interruption as its reason, got %+v", held.Pauses)
+	}
+
+	// Interruptions nest
+	question := InterruptSession(incident, "Question", start.Add(90*time.Minute))
+	if len(question.Interrupted) != 2 || question.Interrupted[1].Tag != "Incident" {
+		t.Fatalf("Expected the stack to be [Feature Incident], got %+v", question.Interrupted)
+	}
+	if len(question.Interrupted[1].Interrupted) != 0 {
+		t.Error("Expected sessions on the stack not to carry their own stack")
+	}
+	if err := SaveSession(question); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	parent, err := CloseSession(question, start.Add(100*time.Minute))
+	if err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if parent == nil || parent.Tag != "Incident" || parent.IsPaused {
+		t.Fatalf("Expected to return to a running Incident, got %+v", parent)
+	}
+	if parent.TotalPaused != 10*time.Minute {
+		t.Errorf("Expected Incident to have 10m paused, got %s", parent.TotalPaused)
+	}
+
+	parent, err = CloseSession(*parent, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if parent == nil || parent.Tag != "Feature" || len(parent.Interrupted) != 0 {
+		t.Fatalf("Expected to return to Feature with an empty stack, got %+v", parent)
+	}
+	if parent.TotalPaused != time.Hour {
+		t.Errorf("Expected the interruption to be covered by paused time, got %s", parent.TotalPaused)
+	}
+	if len(parent.Pauses) != 1 || !parent.Pauses[0].End.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Expected the pause to be closed on return, got %+v", parent.Pauses)
+	}
+	active, err := LoadSession()
+	if err != nil || active.Tag != "Feature" {
+		t.Errorf("Expected Feature to be the active session, got %+v (err %v)", active, err)
+	}
+
+	if parent, err = CloseSession(active, start.Add(3*time.Hour)); err != nil || parent != nil {
+		t.Errorf("Expected closing the last session to return nil, got %+v (err %v)", parent, err)
+	}
+	if SessionExists() {
+		t.Error("Expected the session file to be removed")
+	}
+}
+
+func TestReturnToPausedSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	pausedAt := start.Add(time.Hour)
+	feature := Session{
+		Tag: "Feature", StartTime: start, IsPaused: true, PausedAt: pausedAt,
+		Pauses: []Pause{{Start: pausedAt, Reason: "lunch"}},
+	}
+
+	call := InterruptSession(feature, "Call", start.Add(90*time.Minute))
+	if held := call.Interrupted[0]; len(held.Pauses) != 1 || !held.PausedAt.Equal(pausedAt) {
+		t.Errorf("Expected the paused session to keep its own pause, got %+v", held)
+	}
+	if err := SaveSession(call); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	parent, err := CloseSession(call, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if parent == nil || !parent.IsPaused || !parent.PausedAt.Equal(pausedAt) || parent.TotalPaused != 0 {
+		t.Fatalf("Expected Feature to still be paused since %v, got %+v", pausedAt, parent)
+	}
+	if !parent.Pauses[0].End.IsZero() {
+		t.Errorf("Expected the lunch pause to stay open, got %+v", parent.Pauses)
+	}
+}
+
+func TestCleanupStaleSessionWithStack(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Now().Add(-10 * time.Hour)
+	session := InterruptSession(Session{Tag: "Feature", StartTime: start}, "Incident", start.Add(time.Hour))
+	if err := SaveSession(session); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	if err := CleanupStaleSession(session, true); err != nil {
+		t.Fatalf("CleanupStaleSession() error = %v", err)
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected both sessions to be logged as abandoned, got %d entries", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Status != StatusAbandoned || (entry.Tag != "Feature" && entry.Tag != "Incident") {
+			t.Errorf("Unexpected entry %q (%s)", entry.Tag, entry.Status)
+		}
+		if entry.Tag == "Feature" && entry.Duration != time.Hour {
+			t.Errorf("Expected the held session to stop counting when interrupted, got %s", entry.Duration)
+		}
+	}
+}
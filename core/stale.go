@@ -0,0 +1,163 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stale session policies, set with stale_policy in config.yml
+const (
+	// StalePolicyPrompt asks what to do, falling back to keep without an answer
+	StalePolicyPrompt = "prompt"
+	// StalePolicyTrim logs the session up to the stale threshold
+	StalePolicyTrim = "trim"
+	// StalePolicyLastActivity logs the session up to its last recorded activity
+	StalePolicyLastActivity = "last_activity"
+	// StalePolicyDiscard drops the session without logging it
+	StalePolicyDiscard = "discard"
+	// StalePolicyKeep logs the session's full runtime
+	StalePolicyKeep = "keep"
+)
+
+// StalePolicies lists the valid stale_policy values
+var StalePolicies = []string{StalePolicyPrompt, StalePolicyTrim, StalePolicyLastActivity, StalePolicyDiscard, StalePolicyKeep}
+
+// LastActivityTime returns the last time there is evidence of work on the
+// session: its start, a heartbeat, or a pause or resume.
+func LastActivityTime(session Session) time.Time {
+	last := session.StartTime
+	later := func(t time.Time) {
+		if t.After(last) {
+			last = t
+		}
+	}
+	later(session.LastActivity)
+	for _, pause := range session.Pauses {
+		later(pause.Start)
+		later(pause.End)
+	}
+	if session.IsPaused {
+		later(session.PausedAt)
+	}
+	return last
+}
+
+// PausedBefore returns how much of the session's paused time falls before at
+func PausedBefore(session Session, at time.Time) time.Duration {
+	paused := session.TotalPaused
+	for _, pause := range session.Pauses {
+		if pause.End.IsZero() {
+			continue
+		}
+		if !pause.Start.Before(at) {
+			paused -= pause.Duration()
+		} else if pause.End.After(at) {
+			paused -= pause.End.Sub(at)
+		}
+	}
+	if paused < 0 {
+		return 0
+	}
+	return paused
+}
+
+// StaleEndTime works out where a stale session should end under a policy.
+// It returns false if the session should be discarded. The prompt policy has
+// no answer of its own and is treated as keep.
+func StaleEndTime(session Session, policy string, threshold time.Duration, now time.Time) (time.Time, bool) {
+	end := now
+	if session.IsPaused {
+		end = session.PausedAt
+	}
+
+	switch policy {
+	case StalePolicyDiscard:
+		return time.Time{}, false
+	case StalePolicyTrim:
+		// Stop once the session has counted threshold worth of work
+		trimmed := session.StartTime.Add(threshold)
+		for _, pause := range session.Pauses {
+			if pause.Start.Before(trimmed) {
+				if pause.End.IsZero() {
+					return end, true
+				}
+				trimmed = trimmed.Add(pause.Duration())
+			}
+		}
+		if trimmed.Before(end) {
+			end = trimmed
+		}
+	case StalePolicyLastActivity:
+		if last := LastActivityTime(session); last.Before(end) {
+			end = last
+		}
+	}
+	return end, true
+}
+
+// EndSessionAt logs a session as ending at endTime, which may be earlier than
+// it actually stopped, and removes it along with any sessions it interrupted,
+// which are logged as abandoned. The session itself is logged as abandoned
+// when abandoned is set and as completed otherwise. A pomodoro session logs
+// the phases that finished by endTime and the part of the phase it ends in,
+// as 'flow end' does; phases logged while it ran keep their status.
+func EndSessionAt(session Session, endTime time.Time, abandoned bool) error {
+	if endTime.Before(session.StartTime) {
+		endTime = session.StartTime
+	}
+	if session.IsPaused && endTime.After(session.PausedAt) {
+		endTime = session.PausedAt
+	}
+
+	status := StatusCompleted
+	if abandoned {
+		status = StatusAbandoned
+	}
+
+	if session.Pomodoro != nil {
+		// Phases are logged as they end, so only those not yet logged are
+		_, complete, err := advancePomodoro(&session, endTime, status)
+		if err == nil && !complete {
+			err = finishPomodoro(&session, endTime, status)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to log session: %w", err)
+		}
+		return DiscardSession(session)
+	}
+
+	paused := PausedBefore(session, endTime)
+	duration := endTime.Sub(session.StartTime) - paused
+	if duration < 0 {
+		duration = 0
+	}
+
+	entry := LogEntry{
+		Tag:         session.Tag,
+		Project:     session.Project,
+		Labels:      session.Labels,
+		Git:         session.Git,
+		Status:      status,
+		StartTime:   session.StartTime,
+		EndTime:     endTime,
+		Duration:    duration,
+		TotalPaused: paused,
+		Pauses:      PausesBetween(session.Pauses, session.StartTime, endTime),
+	}
+	addCommits(&entry)
+	if err := LogSession(entry); err != nil {
+		return fmt.Errorf("failed to log session: %w", err)
+	}
+	return DiscardSession(session)
+}
+
+// DiscardSession removes a session without logging it. The sessions it
+// interrupted are logged as abandoned rather than lost with it.
+func DiscardSession(session Session) error {
+	for _, held := range session.Interrupted {
+		if err := logAbandonedSession(held); err != nil {
+			return err
+		}
+	}
+	return RemoveSession()
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaleEndTime(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := start.Add(26 * time.Hour)
+	threshold := 8 * time.Hour
+
+	session := Session{
+		Tag:          "Writing",
+		StartTime:    start,
+		TotalPaused:  time.Hour,
+		LastActivity: start.Add(3 * time.Hour),
+		Pauses: []Pause{
+			{Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Reason: "lunch"},
+		},
+	}
+
+	tests := []struct {
+		policy   string
+		wantEnd  time.Time
+		wantKeep bool
+	}{
+		{StalePolicyKeep, now, true},
+		{StalePolicyPrompt, now, true},
+		// 8h of work plus the hour-long pause
+		{StalePolicyTrim, start.Add(9 * time.Hour), true},
+		{StalePolicyLastActivity, start.Add(3 * time.Hour), true},
+		{StalePolicyDiscard, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			end, keep := StaleEndTime(session, tt.policy, threshold, now)
+			if keep != tt.wantKeep || !end.Equal(tt.wantEnd) {
+				t.Errorf("StaleEndTime() = %v, %v; want %v, %v", end, keep, tt.wantEnd, tt.wantKeep)
+			}
+		})
+	}
+
+	// A paused session never ends after it was paused
+	paused := session
+	paused.IsPaused = true
+	paused.PausedAt = start.Add(4 * time.Hour)
+	if end, _ := StaleEndTime(paused, StalePolicyTrim, threshold, now); !end.Equal(paused.PausedAt) {
+		t.Errorf("Expected a paused session to end when it was paused, got %v", end)
+	}
+}
+
+func TestLastActivityTime(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	if got := LastActivityTime(Session{StartTime: start}); !got.Equal(start) {
+		t.Errorf("Expected a session without activity to fall back to its start, got %v", got)
+	}
+
+	session := Session{
+		StartTime:    start,
+		LastActivity: start.Add(time.Hour),
+		Pauses:       []Pause{{Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)}},
+	}
+	if got := LastActivityTime(session); !got.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("Expected the last resume to count as activity, got %v", got)
+	}
+}
+
+func TestEndSessionAt(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Now().Add(-20 * time.Hour)
+	session := Session{
+		Tag:         "Writing",
+		StartTime:   start,
+		TotalPaused: 2 * time.Hour,
+		Pauses: []Pause{
+			{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)},
+			{Start: start.Add(10 * time.Hour), End: start.Add(11*time.Hour + 30*time.Minute)},
+		},
+	}
+	if err := SaveSession(session); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	if err := EndSessionAt(session, start.Add(4*time.Hour), true); err != nil {
+		t.Fatalf("EndSessionAt() error = %v", err)
+	}
+	if SessionExists() {
+		t.Error("Expected the session file to be removed")
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected one log entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Tag != "Writing" || entry.Status != StatusAbandoned {
+		t.Errorf("Expected an abandoned Writing entry, got %q (%s)", entry.Tag, entry.Status)
+	}
+	// Only the pause before the new end counts
+	if entry.TotalPaused != 30*time.Minute || entry.Duration != 210*time.Minute {
+		t.Errorf("Expected 3h 30m worked with 30m paused, got %s worked with %s paused", entry.Duration, entry.TotalPaused)
+	}
+	if len(entry.Pauses) != 1 {
+		t.Errorf("Expected only the first pause to be logged, got %+v", entry.Pauses)
+	}
+}
+
+func TestEndSessionAtPomodoro(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Now().Add(-20 * time.Hour)
+	plan := Pomodoro{Work: 25 * time.Minute, Break: 5 * time.Minute, Rounds: 4}
+	session := Session{Tag: "sprint", StartTime: start, Pomodoro: StartPomodoro(plan, start)}
+
+	// The first round was logged as it happened, before the session went stale
+	if _, _, err := AdvancePomodoro(&session, start.Add(40*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	// Trimmed to an hour and abandoned: rounds 1 and 2, and 5 minutes of round 3
+	if err := EndSessionAt(session, start.Add(65*time.Minute), true); err != nil {
+		t.Fatalf("EndSessionAt() error = %v", err)
+	}
+	if SessionExists() {
+		t.Error("Expected the session file to be removed")
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	var focus time.Duration
+	for _, entry := range entries {
+		if entry.Phase != PhaseWork {
+			t.Errorf("Expected only work phases in the log, got %+v", entry)
+		}
+		// Only the round logged while the session ran was completed
+		want := StatusAbandoned
+		if entry.Round == 1 {
+			want = StatusCompleted
+		}
+		if entry.Status != want {
+			t.Errorf("Round %d logged as %s, want %s", entry.Round, entry.Status, want)
+		}
+		focus += entry.Duration
+	}
+	if len(entries) != 3 || focus != 55*time.Minute {
+		t.Errorf("Expected 3 work phases totalling 55m, got %d totalling %s", len(entries), focus)
+	}
+}
+
+func TestDiscardSession(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	start := time.Now().Add(-20 * time.Hour)
+	held := Session{Tag: "Feature", StartTime: start, IsPaused: true, PausedAt: start.Add(time.Hour)}
+	session := Session{Tag: "Call", StartTime: start.Add(time.Hour), Interrupted: []Session{held}}
+	if err := SaveSession(session); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DiscardSession(session); err != nil {
+		t.Fatalf("DiscardSession() error = %v", err)
+	}
+	if SessionExists() {
+		t.Error("Expected the session file to be removed")
+	}
+
+	// The discarded session isn't logged, but the one it interrupted is
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	if len(entries) != 1 || entries[0].Tag != "Feature" || entries[0].Status != StatusAbandoned || entries[0].Duration != time.Hour {
+		t.Errorf("Expected the held session to be logged as abandoned, got %+v", entries)
+	}
+}
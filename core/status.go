@@ -0,0 +1,168 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Log entry statuses
+const (
+	// StatusCompleted is a session that was ended normally
+	StatusCompleted = "completed"
+	// StatusAbandoned is a stale session that was cleaned up without being ended
+	StatusAbandoned = "abandoned"
+	// StatusCancelled is a session discarded with 'flow cancel'; these live in
+	// the cancel log rather than the session log
+	StatusCancelled = "cancelled"
+	// StatusManual is a session logged after the fact with 'flow add'
+	StatusManual = "manual"
+	// StatusImported is a session brought in from elsewhere
+	StatusImported = "imported"
+)
+
+// Statuses lists every log entry status
+var Statuses = []string{StatusCompleted, StatusAbandoned, StatusCancelled, StatusManual, StatusImported}
+
+// abandonedSuffix is how older versions marked abandoned sessions in the tag
+const abandonedSuffix = " [ABANDONED]"
+
+// normalizeStatus fills in the status of entries written before it existed,
+// turning the old tag suffix for abandoned sessions into a status.
+func normalizeStatus(entry *LogEntry) {
+	if entry.Status != "" {
+		return
+	}
+	entry.Status = StatusCompleted
+	if strings.HasSuffix(entry.Tag, abandonedSuffix) {
+		entry.Tag = strings.TrimSuffix(entry.Tag, abandonedSuffix)
+		entry.Status = StatusAbandoned
+	}
+}
+
+// StatusFilter selects log entries by status. An empty Include selects every
+// status kept in the session log, which leaves out cancelled sessions.
+type StatusFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseStatusFilter builds a filter from comma-separated lists of statuses
+func ParseStatusFilter(include, exclude string) (StatusFilter, error) {
+	var filter StatusFilter
+	var err error
+	if filter.Include, err = parseStatusList(include); err != nil {
+		return filter, err
+	}
+	if filter.Exclude, err = parseStatusList(exclude); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseStatusList(value string) ([]string, error) {
+	var statuses []string
+	for _, part := range strings.Split(value, ",") {
+		status := strings.ToLower(strings.TrimSpace(part))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(Statuses, status) {
+			return nil, fmt.Errorf("unknown status %q (valid: %s)", status, strings.Join(Statuses, ", "))
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// IsZero reports whether the filter is the default one
+func (f StatusFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Matches reports whether an entry with the given status passes the filter
+func (f StatusFilter) Matches(status string) bool {
+	if slices.Contains(f.Exclude, status) {
+		return false
+	}
+	if len(f.Include) == 0 {
+		return status != StatusCancelled
+	}
+	return slices.Contains(f.Include, status)
+}
+
+// FilterByStatus returns the entries that pass the filter
+func FilterByStatus(entries []LogEntry, filter StatusFilter) []LogEntry {
+	if filter.IsZero() {
+		return entries
+	}
+	filtered := []LogEntry{}
+	for _, entry := range entries {
+		if filter.Matches(entry.Status) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// ReadCancelledEntries returns the sessions in every device's cancel log as
+// log entries with the cancelled status, ending when they were cancelled.
+func ReadCancelledEntries() ([]LogEntry, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := deviceFiles(filepath.Join(logDir, "cancelled.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, path := range paths {
+		fileLines, err := readLines(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		lines = append(lines, fileLines...)
+	}
+
+	var entries []LogEntry
+	for _, line := range lines {
+		var record CancelRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			continue
+		}
+		session := record.Session
+		endTime := record.CancelledAt
+		if session.IsPaused {
+			endTime = session.PausedAt
+		}
+		duration := endTime.Sub(session.StartTime) - session.TotalPaused
+		if duration < 0 {
+			duration = 0
+		}
+		entry := LogEntry{
+			Tag:         session.Tag,
+			Project:     session.Project,
+			Labels:      session.Labels,
+			Git:         session.Git,
+			StartTime:   session.StartTime,
+			EndTime:     endTime,
+			Duration:    duration,
+			TotalPaused: session.TotalPaused,
+			Pauses:      PausesBetween(session.Pauses, session.StartTime, endTime),
+			Status:      StatusCancelled,
+		}
+		entry.ID = legacyID(entry, line)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// inMonth reports whether t falls in the same calendar month as month
+func inMonth(t, month time.Time) bool {
+	return t.Year() == month.Year() && t.Month() == month.Month()
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLogLineStatus(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantTag    string
+		wantStatus string
+	}{
+		{`{"tag":"Writing","start_time":"2025-03-10T09:00:00Z","end_time":"2025-03-10T10:00:00Z","duration":3600000000000}`, "Writing", StatusCompleted},
+		{`{"tag":"Writing [ABANDONED]","start_time":"2025-03-10T09:00:00Z","end_time":"2025-03-10T10:00:00Z","duration":3600000000000}`, "Writing", StatusAbandoned},
+		{`{"tag":"Reading","start_time":"2025-03-10T09:00:00Z","end_time":"2025-03-10T10:00:00Z","duration":3600000000000,"status":"manual"}`, "Reading", StatusManual},
+	}
+
+	for _, tt := range tests {
+		entry, err := parseLogLine(tt.line)
+		if err != nil {
+			t.Fatalf("parseLogLine() error = %v", err)
+		}
+		if entry.Tag != tt.wantTag || entry.Status != tt.wantStatus {
+			t.Errorf("parseLogLine() = %q (%s), want %q (%s)", entry.Tag, entry.Status, tt.wantTag, tt.wantStatus)
+		}
+	}
+}
+
+func TestStatusFilter(t *testing.T) {
+	if _, err := ParseStatusFilter("completed,bogus", ""); err == nil {
+		t.Error("Expected an error for an unknown status")
+	}
+
+	filter, err := ParseStatusFilter("", "abandoned")
+	if err != nil {
+		t.Fatalf("ParseStatusFilter() error = %v", err)
+	}
+	for status, want := range map[string]bool{
+		StatusCompleted: true,
+		StatusManual:    true,
+		StatusAbandoned: false,
+		StatusCancelled: false,
+	} {
+		if got := filter.Matches(status); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", status, got, want)
+		}
+	}
+
+	filter, _ = ParseStatusFilter(" Cancelled , manual", "")
+	if !filter.Matches(StatusCancelled) || !filter.Matches(StatusManual) || filter.Matches(StatusCompleted) {
+		t.Errorf("Unexpected matches for %+v", filter)
+	}
+}
+
+func TestLogReaderStatusFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tempDir)
+	t.Setenv("FLOW_SESSION_PATH", filepath.Join(tempDir, "session"))
+
+	now := time.Now()
+	logDir := filepath.Join(tempDir, "flow", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// An abandoned session as older versions wrote it
+	legacy := `{"tag":"Writing [ABANDONED]","start_time":"` + now.Add(-3*time.Hour).Format(time.RFC3339) +
+		`","end_time":"` + now.Add(-2*time.Hour).Format(time.RFC3339) + `","duration":3600000000000}` + "\n"
+	logPath, _ := GetLogPath(now.Add(-2 * time.Hour))
+	if err := os.WriteFile(logPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LogSession(LogEntry{Tag: "Writing", StartTime: now.Add(-time.Hour), EndTime: now, Duration: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	oops := Session{Tag: "Oops", StartTime: now.Add(-time.Minute)}
+	if err := SaveSession(oops); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CancelSession(oops, true); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, _ := NewLogReader()
+	entries, _ := reader.ReadAllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected the two logged sessions by default, got %d", len(entries))
+	}
+	if stats := CalculateStats(entries); len(stats.TopActivities) != 1 {
+		t.Errorf("Expected abandoned and completed sessions to share a tag, got %+v", stats.TopActivities)
+	}
+
+	filter, _ := ParseStatusFilter("", StatusAbandoned)
+	reader.SetStatusFilter(filter)
+	if entries, _ := reader.ReadAllEntries(); len(entries) != 1 || entries[0].Status != StatusCompleted {
+		t.Errorf("Expected only the completed session, got %+v", entries)
+	}
+
+	filter, _ = ParseStatusFilter(StatusCancelled, "")
+	reader.SetStatusFilter(filter)
+	if entries, _ := reader.ReadAllEntries(); len(entries) != 1 || entries[0].Tag != "Oops" {
+		t.Errorf("Expected only the cancelled session, got %+v", entries)
+	}
+
+	// Cancelled sessions are merged into the log in order
+	reader.SetStatusFilter(allStatuses)
+	entries, err := reader.Query(LogQuery{Order: OldestFirst, Limit: 3})
+	if err != nil || len(entries) != 3 || entries[0].Status != StatusAbandoned || entries[2].Tag != "Oops" {
+		t.Errorf("Query(oldest first) = %+v, %v", entries, err)
+	}
+	entries, err = reader.Query(LogQuery{Limit: 1})
+	if err != nil || len(entries) != 1 || entries[0].Tag != "Oops" {
+		t.Errorf("Query(limit 1) = %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Storage backends, selected with the 'storage' setting in config.yml
+const (
+	StorageJSONL  = "jsonl"
+	StorageSQLite = "sqlite"
+)
+
+// StorageBackends lists the valid storage settings
+var StorageBackends = []string{StorageJSONL, StorageSQLite}
+
+// Store persists the session log and the active session
+type Store interface {
+	// Append adds an entry to the log
+	Append(entry LogEntry) error
+	// Entries streams the logged entries selected by q in q's order. Entries
+	// are read as the caller iterates, so breaking out of the loop, or
+	// reaching q's limit, stops reading.
+	Entries(q LogQuery) iter.Seq2[LogEntry, error]
+	// Summarize totals the entries selected by q
+	Summarize(q LogQuery) (*Summary, error)
+	// Reindex rebuilds any summary index the backend keeps
+	Reindex() (IndexReport, error)
+	// Update replaces a logged entry, identified by original, with updated
+	Update(original, updated LogEntry) error
+	// Delete removes a logged entry
+	Delete(entry LogEntry) error
+
+	// GetSession returns the active session, or an error satisfying
+	// os.IsNotExist if there is none
+	GetSession() (Session, error)
+	// PutSession makes session the active session
+	PutSession(session Session) error
+	// DeleteSession removes the active session
+	DeleteSession() error
+
+	// UpgradeSchema rewrites stored entries in the current schema, reporting
+	// the versions found. With dryRun nothing is written.
+	UpgradeSchema(dryRun bool) ([]SchemaFile, error)
+
+	// Close releases the store's resources
+	Close() error
+}
+
+// Order is the order entries are returned in, by end time
+type Order int
+
+const (
+	NewestFirst Order = iota
+	OldestFirst
+)
+
+// LogQuery selects logged entries. Entries are matched on their end time;
+// a zero From or To leaves that end of the range open. A Limit of 0 returns
+// every matching entry.
+type LogQuery struct {
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Order    Order
+	Statuses StatusFilter
+	Projects ProjectFilter
+	Repos    RepoFilter
+	// Tags selects entries with any of these tags, ignoring case
+	Tags []string
+	// Where, if set, is an extra predicate entries must satisfy
+	Where func(LogEntry) bool
+}
+
+// Matches reports whether an entry falls in the query's range and passes its filters
+func (q LogQuery) Matches(entry LogEntry) bool {
+	if !q.From.IsZero() && entry.EndTime.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !entry.EndTime.Before(q.To) {
+		return false
+	}
+	if len(q.Tags) > 0 && !slices.ContainsFunc(q.Tags, func(tag string) bool { return strings.EqualFold(tag, entry.Tag) }) {
+		return false
+	}
+	if !q.Statuses.Matches(entry.Status) || !q.Projects.Matches(entry) || !q.Repos.Matches(entry) {
+		return false
+	}
+	return q.Where == nil || q.Where(entry)
+}
+
+// before reports whether a comes before b in the query's order
+func (q LogQuery) before(a, b LogEntry) bool {
+	if q.Order == OldestFirst {
+		return a.EndTime.Before(b.EndTime)
+	}
+	return a.EndTime.After(b.EndTime)
+}
+
+// sort orders entries in the query's order
+func (q LogQuery) sort(entries []LogEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return q.before(entries[i], entries[j])
+	})
+}
+
+// emitter passes the entries matching a query on to an iterator's consumer,
+// stopping once the query's limit is reached
+type emitter struct {
+	q     LogQuery
+	yield func(LogEntry, error) bool
+	count int
+}
+
+// emit yields entry if it matches and reports whether to keep going
+func (e *emitter) emit(entry LogEntry) bool {
+	if !e.q.Matches(entry) {
+		return true
+	}
+	return e.send(entry)
+}
+
+// send yields an entry already known to match and reports whether to keep going
+func (e *emitter) send(entry LogEntry) bool {
+	if !e.yield(entry, nil) {
+		return false
+	}
+	e.count++
+	return e.q.Limit <= 0 || e.count < e.q.Limit
+}
+
+// collect gathers the entries from an iterator, stopping at the first error
+func collect(entries iter.Seq2[LogEntry, error]) ([]LogEntry, error) {
+	result := []LogEntry{}
+	for entry, err := range entries {
+		if err != nil {
+			return result, err
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// OpenStore opens the storage backend selected in config.yml
+func OpenStore() (Store, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return OpenStoreBackend(config.Storage)
+}
+
+// OpenStoreBackend opens a storage backend by name. An empty name is the
+// default JSONL backend.
+func OpenStoreBackend(backend string) (Store, error) {
+	switch backend {
+	case "", StorageJSONL:
+		return NewJSONLStore()
+	case StorageSQLite:
+		return NewSQLiteStore()
+	}
+	return nil, fmt.Errorf("unknown storage backend %q (valid: %s)", backend, strings.Join(StorageBackends, ", "))
+}
+
+// withStore runs fn against the configured store and closes it afterwards
+func withStore(fn func(store Store) error) (err error) {
+	store, err := OpenStore()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := store.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return fn(store)
+}
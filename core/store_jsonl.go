@@ -0,0 +1,444 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonlStore is the default backend: the log is kept in monthly JSON Lines
+// files named after the month entries end in, and the active session in its
+// own file.
+type jsonlStore struct {
+	logDir      string
+	sessionPath string
+	// device is set in sync mode, where each device writes its own files
+	device string
+}
+
+// NewJSONLStore opens the JSONL store in the log and session locations
+func NewJSONLStore() (Store, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return nil, err
+	}
+	sessionPath, err := GetSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlStore{logDir: logDir, sessionPath: sessionPath, device: syncDevice()}, nil
+}
+
+// logPath returns the file this device writes entries ending at date to
+func (s *jsonlStore) logPath(date time.Time) (string, error) {
+	logPath, err := GetLogPath(date)
+	if err != nil || s.device == "" {
+		return logPath, err
+	}
+	return deviceLogPath(logPath, s.device), nil
+}
+
+// entryPath returns the monthly file holding an entry. In sync mode that may
+// be any device's file for the month, but only the device that logged an
+// entry may change it; an entry found in none is looked for in this device's,
+// where it is reported missing.
+func (s *jsonlStore) entryPath(entry LogEntry) (string, error) {
+	logPath, err := GetLogPath(entry.EndTime)
+	if err != nil || s.device == "" {
+		return logPath, err
+	}
+	files, err := filepath.Glob(strings.TrimSuffix(logPath, ".jsonl") + "*")
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if _, _, ok := parseLogFileName(file); !ok {
+			continue
+		}
+		entries, _, err := readLogFile(file)
+		if err != nil {
+			return "", err
+		}
+		for _, stored := range entries {
+			if !isSameEntry(stored, entry) {
+				continue
+			}
+			if otherDevices(file, s.device) {
+				return "", fmt.Errorf("%q was logged on %s; change it on that device", stored.Tag, fileDevice(file))
+			}
+			return strings.TrimSuffix(file, archiveSuffix), nil
+		}
+	}
+	return deviceLogPath(logPath, s.device), nil
+}
+
+// Append adds an entry to the file for the month it ended in
+func (s *jsonlStore) Append(entry LogEntry) error {
+	logPath, err := s.logPath(entry.EndTime)
+	if err != nil {
+		return err
+	}
+	return s.updateIndex(logPath, func() ([]LogEntry, []LogEntry, error) {
+		// Summarise the entry as it will be read back
+		stored := entry
+		normalizeStatus(&stored)
+		return []LogEntry{stored}, nil, appendLogEntry(logPath, entry)
+	})
+}
+
+// Entries reads the monthly files that can hold entries in the query's range
+// one at a time, in the query's order. Entries near the start or end of a
+// month may be filed in its neighbour, so those are held back until the
+// neighbouring file has been read.
+func (s *jsonlStore) Entries(q LogQuery) iter.Seq2[LogEntry, error] {
+	return func(yield func(LogEntry, error) bool) {
+		files, err := s.logFiles(q.From, q.To)
+		if err != nil {
+			yield(LogEntry{}, err)
+			return
+		}
+		if q.Order == OldestFirst {
+			slices.Reverse(files)
+		}
+
+		out := emitter{q: q, yield: yield}
+		var pending []LogEntry
+		for i, file := range files {
+			fileEntries, _, err := readLogFile(file)
+			if err != nil {
+				// Log error but continue with other files
+				fmt.Fprintf(os.Stderr, "Warning: error reading %s: %v\n", file, err)
+				continue
+			}
+			for _, entry := range fileEntries {
+				if q.Matches(entry) {
+					pending = append(pending, entry)
+				}
+			}
+			q.sort(pending)
+
+			// Only entries that no file still to be read can come before are safe to pass on
+			month, ok := logFileMonth(file)
+			if !ok {
+				continue
+			}
+			// A month can have an archive as well as a plain file
+			if i+1 < len(files) {
+				if next, ok := logFileMonth(files[i+1]); ok && next.Equal(month) {
+					continue
+				}
+			}
+			ready := len(pending)
+			if q.Order == OldestFirst {
+				bound := month.AddDate(0, 1, -1)
+				ready = sort.Search(len(pending), func(i int) bool { return !pending[i].EndTime.Before(bound) })
+			} else {
+				bound := month.AddDate(0, 0, 1)
+				ready = sort.Search(len(pending), func(i int) bool { return pending[i].EndTime.Before(bound) })
+			}
+			for _, entry := range pending[:ready] {
+				if !out.send(entry) {
+					return
+				}
+			}
+			pending = slices.Delete(pending, 0, ready)
+		}
+		for _, entry := range pending {
+			if !out.send(entry) {
+				return
+			}
+		}
+	}
+}
+
+// logFileMonth parses the month a log file holds from its name
+func logFileMonth(file string) (time.Time, bool) {
+	month, _, ok := parseLogFileName(file)
+	return month, ok
+}
+
+// parseLogFileName parses the month a log file holds from its name, and the
+// device that writes it for a device's file in sync mode
+func parseLogFileName(file string) (month time.Time, device string, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(file), archiveSuffix)
+	prefix, rest, found := strings.Cut(name, "_sessions")
+	if !found {
+		return time.Time{}, "", false
+	}
+	if rest != ".jsonl" {
+		device = strings.TrimSuffix(strings.TrimPrefix(rest, "."), ".jsonl")
+		if !strings.HasSuffix(rest, ".jsonl") || !validDevice(device) {
+			return time.Time{}, "", false
+		}
+	}
+	month, err := time.ParseInLocation("200601", prefix, time.Local)
+	return month, device, err == nil
+}
+
+// logFiles returns the monthly log files that may hold entries ending in
+// [from, to), newest first. Entries are filed by their end time in the time
+// zone they were logged in, so each month is widened by a day on either side.
+func (s *jsonlStore) logFiles(from, to time.Time) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.logDir, "*_sessions.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	archives, err := filepath.Glob(filepath.Join(s.logDir, "*_sessions.jsonl"+archiveSuffix))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, archives...)
+	// Files other devices write in sync mode; copies a sync tool made of a
+	// conflicting file don't follow the naming scheme and are left out
+	devices, err := filepath.Glob(filepath.Join(s.logDir, "*_sessions.*.jsonl*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range devices {
+		if _, device, ok := parseLogFileName(file); ok && device != "" {
+			files = append(files, file)
+		}
+	}
+
+	var relevant []string
+	for _, file := range files {
+		month, ok := logFileMonth(file)
+		if !ok {
+			// Files that don't follow the naming scheme are only read in full
+			if from.IsZero() && to.IsZero() {
+				relevant = append(relevant, file)
+			}
+			continue
+		}
+		if !from.IsZero() && !month.AddDate(0, 1, 1).After(from) {
+			continue
+		}
+		if !to.IsZero() && !month.AddDate(0, 0, -1).Before(to) {
+			continue
+		}
+		relevant = append(relevant, file)
+	}
+
+	// Lexicographically, newer YYYYMM comes after older
+	sort.Sort(sort.Reverse(sort.StringSlice(relevant)))
+	return relevant, nil
+}
+
+// Update rewrites the entry in place, moving it to a different monthly file
+// if its end time now falls in another month
+func (s *jsonlStore) Update(original, updated LogEntry) error {
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+
+	oldMonth, err := GetLogPath(original.EndTime)
+	if err != nil {
+		return err
+	}
+	newMonth, err := GetLogPath(updated.EndTime)
+	if err != nil {
+		return err
+	}
+	oldPath, err := s.entryPath(original)
+	if err != nil {
+		return err
+	}
+
+	if oldMonth == newMonth {
+		if err := s.unarchive(oldPath); err != nil {
+			return err
+		}
+		return s.updateIndex(oldPath, func() (added, removed []LogEntry, err error) {
+			stored, err := parseLogLine(string(data))
+			if err != nil {
+				return nil, nil, err
+			}
+			// An edit that changes nothing leaves the file as it is, so
+			// whether the entry was there is tracked separately
+			found := false
+			_, err = rewriteLogFile(oldPath, func(entry LogEntry, line string) (string, bool) {
+				if isSameEntry(entry, original) {
+					found = true
+					added = append(added, stored)
+					removed = append(removed, entry)
+					return string(data), true
+				}
+				return line, true
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			if !found {
+				return nil, nil, fmt.Errorf("log entry not found")
+			}
+			return added, removed, nil
+		})
+	}
+
+	// Write the entry to its new month before removing the old copy, so an
+	// interruption leaves a duplicate rather than losing the session.
+	newPath, err := s.logPath(updated.EndTime)
+	if err != nil {
+		return err
+	}
+	if err := appendLogEntry(newPath, updated); err != nil {
+		return err
+	}
+	if err := s.Delete(original); err != nil {
+		return fmt.Errorf("entry copied to %s but not removed from %s: %w", newPath, oldPath, err)
+	}
+	return nil
+}
+
+// Delete removes the entry from the file for the month it ended in
+func (s *jsonlStore) Delete(target LogEntry) error {
+	logPath, err := s.entryPath(target)
+	if err != nil {
+		return err
+	}
+	if err := s.unarchive(logPath); err != nil {
+		return err
+	}
+
+	return s.updateIndex(logPath, func() (added, removed []LogEntry, err error) {
+		found, err := rewriteLogFile(logPath, func(entry LogEntry, line string) (string, bool) {
+			if isSameEntry(entry, target) {
+				removed = append(removed, entry)
+				return line, false
+			}
+			return line, true
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("log entry not found")
+		}
+		return nil, removed, nil
+	})
+}
+
+// UpgradeSchema rewrites each monthly file in the current schema, leaving
+// other devices' files to them in sync mode
+func (s *jsonlStore) UpgradeSchema(dryRun bool) ([]SchemaFile, error) {
+	paths, err := s.logFiles(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	// Report oldest first
+	sort.Strings(paths)
+
+	var files []SchemaFile
+	for _, path := range paths {
+		// Archived entries are upgraded as they are read
+		if isArchive(path) || otherDevices(path, s.device) {
+			continue
+		}
+		file, err := upgradeLogFile(path, dryRun)
+		files = append(files, file)
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// GetSession reads the session file
+func (s *jsonlStore) GetSession() (Session, error) {
+	var session Session
+	data, err := os.ReadFile(s.sessionPath)
+	if err != nil {
+		return session, err
+	}
+	err = json.Unmarshal(data, &session)
+	return session, err
+}
+
+// PutSession replaces the session file
+func (s *jsonlStore) PutSession(session Session) error {
+	// Ensure the directory exists
+	if err := ensureDir(s.sessionPath); err != nil {
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename it into place, so readers never see a
+	// partially written session.
+	tempFile, err := os.CreateTemp(filepath.Dir(s.sessionPath), ".session_")
+	if err != nil {
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	if err := os.Rename(tempFile.Name(), s.sessionPath); err != nil {
+		_ = os.Remove(tempFile.Name())
+		return err
+	}
+	return nil
+}
+
+// DeleteSession removes the session file
+func (s *jsonlStore) DeleteSession() error {
+	return os.Remove(s.sessionPath)
+}
+
+// Close is a no-op; files are only open while they are being read or written
+func (s *jsonlStore) Close() error {
+	return nil
+}
+
+// readLogFile reads the entries from a single log file, skipping malformed
+// lines. Archived files are decompressed as they are read.
+func readLogFile(filePath string) (entries []LogEntry, lineCount int, err error) {
+	file, err := openLogFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		lineCount++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		entry, err := parseLogLine(line)
+		if err != nil {
+			// Skip malformed lines but continue processing
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, lineCount, scanner.Err()
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite" // Pure Go driver registered as "sqlite"
+)
+
+// sqliteSchema creates the tables used by the SQLite store. Entries are kept
+// as JSON alongside the columns they are looked up by, so new fields don't
+// need schema changes.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id         TEXT PRIMARY KEY,
+	tag        TEXT NOT NULL,
+	start_time INTEGER NOT NULL,
+	end_time   INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_end_time ON entries (end_time);
+CREATE TABLE IF NOT EXISTS session (
+	id   INTEGER PRIMARY KEY CHECK (id = 1),
+	data TEXT NOT NULL
+);`
+
+// sqliteStore keeps the log and the active session in a single SQLite database
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// GetDatabasePath returns the path of the SQLite database, next to the logs directory
+func GetDatabasePath() (string, error) {
+	logDir, err := GetLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(logDir), "flow.db"), nil
+}
+
+// NewSQLiteStore opens the SQLite store, creating the database if needed
+func NewSQLiteStore() (Store, error) {
+	path, err := GetDatabasePath()
+	if err != nil {
+		return nil, err
+	}
+	return openSQLiteStore(path)
+}
+
+// openSQLiteStore opens the SQLite database at path
+func openSQLiteStore(path string) (Store, error) {
+	if err := ensureDir(path); err != nil {
+		return nil, err
+	}
+
+	// Wait for other flow processes instead of failing with SQLITE_BUSY
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to prepare database %s: %w", path, err)
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+// Append inserts an entry
+func (s *sqliteStore) Append(entry LogEntry) error {
+	if entry.ID == "" {
+		entry.ID = NewID(entry.StartTime)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO entries (id, tag, start_time, end_time, data) VALUES (?, ?, ?, ?, ?)`,
+		entry.ID, entry.Tag, entry.StartTime.UnixNano(), entry.EndTime.UnixNano(), string(data))
+	return err
+}
+
+// Entries reads entries in the query's order as the caller iterates
+func (s *sqliteStore) Entries(q LogQuery) iter.Seq2[LogEntry, error] {
+	return func(yield func(LogEntry, error) bool) {
+		var conditions []string
+		var args []any
+		if !q.From.IsZero() {
+			conditions = append(conditions, "end_time >= ?")
+			args = append(args, q.From.UnixNano())
+		}
+		if !q.To.IsZero() {
+			conditions = append(conditions, "end_time < ?")
+			args = append(args, q.To.UnixNano())
+		}
+		query := "SELECT data FROM entries"
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		if q.Order == OldestFirst {
+			query += " ORDER BY end_time ASC"
+		} else {
+			query += " ORDER BY end_time DESC"
+		}
+
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			yield(LogEntry{}, err)
+			return
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		out := emitter{q: q, yield: yield}
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				yield(LogEntry{}, err)
+				return
+			}
+			entry, err := parseLogLine(data)
+			if err != nil {
+				// Skip malformed rows, as the JSONL store skips malformed lines
+				continue
+			}
+			if !out.emit(entry) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(LogEntry{}, err)
+		}
+	}
+}
+
+// Summarize totals the entries selected by q as they are read. The database
+// indexes entries by end time, so no summary index is kept.
+func (s *sqliteStore) Summarize(q LogQuery) (*Summary, error) {
+	return summarize(s.Entries(q))
+}
+
+// Reindex is a no-op; the SQLite store keeps no summary index
+func (s *sqliteStore) Reindex() (IndexReport, error) {
+	return IndexReport{}, nil
+}
+
+// entryCondition selects a stored entry by ID, or by start time and tag for
+// entries without one
+func entryCondition(entry LogEntry) (string, []any) {
+	if entry.ID != "" {
+		return "id = ?", []any{entry.ID}
+	}
+	return "start_time = ? AND tag = ?", []any{entry.StartTime.UnixNano(), entry.Tag}
+}
+
+// Update replaces a stored entry
+func (s *sqliteStore) Update(original, updated LogEntry) error {
+	data, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	where, whereArgs := entryCondition(original)
+	args := append([]any{updated.Tag, updated.StartTime.UnixNano(), updated.EndTime.UnixNano(), string(data)}, whereArgs...)
+	result, err := s.db.Exec("UPDATE entries SET tag = ?, start_time = ?, end_time = ?, data = ? WHERE "+where, args...)
+	return entryChanged(result, err)
+}
+
+// Delete removes a stored entry
+func (s *sqliteStore) Delete(entry LogEntry) error {
+	where, args := entryCondition(entry)
+	result, err := s.db.Exec("DELETE FROM entries WHERE "+where, args...)
+	return entryChanged(result, err)
+}
+
+// entryChanged turns a statement that touched no rows into a not found error
+func entryChanged(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("log entry not found")
+	}
+	return nil
+}
+
+// UpgradeSchema rewrites the entries table in the current schema
+func (s *sqliteStore) UpgradeSchema(dryRun bool) ([]SchemaFile, error) {
+	file := SchemaFile{Name: "entries"}
+	rows, err := s.db.Query("SELECT id, data FROM entries ORDER BY end_time")
+	if err != nil {
+		return nil, err
+	}
+	upgraded := map[string]string{}
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		newData, version, err := upgradeLine(data)
+		if err != nil {
+			file.Malformed++
+			continue
+		}
+		file.record(version, newData != data)
+		if newData != data {
+			upgraded[id] = newData
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if dryRun || len(upgraded) == 0 {
+		return []SchemaFile{file}, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	for id, data := range upgraded {
+		entry, err := parseLogLine(data)
+		if err == nil {
+			_, err = tx.Exec("UPDATE entries SET tag = ?, data = ? WHERE id = ?", entry.Tag, data, id)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	return []SchemaFile{file}, tx.Commit()
+}
+
+// GetSession reads the active session
+func (s *sqliteStore) GetSession() (Session, error) {
+	var session Session
+	var data string
+	err := s.db.QueryRow("SELECT data FROM session WHERE id = 1").Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, os.ErrNotExist
+	}
+	if err != nil {
+		return session, err
+	}
+	err = json.Unmarshal([]byte(data), &session)
+	return session, err
+}
+
+// PutSession replaces the active session
+func (s *sqliteStore) PutSession(session Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT OR REPLACE INTO session (id, data) VALUES (1, ?)", string(data))
+	return err
+}
+
+// DeleteSession removes the active session
+func (s *sqliteStore) DeleteSession() error {
+	result, err := s.db.Exec("DELETE FROM session WHERE id = 1")
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return os.ErrNotExist
+	}
+	return err
+}
+
+// Close closes the database
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
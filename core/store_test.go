@@ -0,0 +1,288 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestStore opens a backend in an empty data directory
+func openTestStore(t *testing.T, backend string) Store {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FLOW_LOG_PATH", "")
+	t.Setenv("FLOW_SESSION_PATH", "")
+
+	store, err := OpenStoreBackend(backend)
+	if err != nil {
+		t.Fatalf("OpenStoreBackend(%q) error = %v", backend, err)
+	}
+	t.Cleanup(func() {
+		if err := store.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+	return store
+}
+
+func TestStoreBackends(t *testing.T) {
+	for _, backend := range StorageBackends {
+		t.Run(backend, func(t *testing.T) {
+			store := openTestStore(t, backend)
+
+			jan := time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC)
+			feb := time.Date(2025, 2, 10, 10, 0, 0, 0, time.UTC)
+			entries := []LogEntry{
+				{ID: NewID(jan), Tag: "January", StartTime: jan, EndTime: jan.Add(time.Hour), Duration: time.Hour, Status: StatusCompleted, Project: "acme/web"},
+				{ID: NewID(feb), Tag: "February", StartTime: feb, EndTime: feb.Add(time.Hour), Duration: time.Hour, Status: StatusAbandoned},
+				{ID: NewID(feb.Add(2 * time.Hour)), Tag: "Later", StartTime: feb.Add(2 * time.Hour), EndTime: feb.Add(3 * time.Hour), Duration: time.Hour, Status: StatusManual},
+			}
+			for _, entry := range entries {
+				if err := store.Append(entry); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+
+			tags := func(q LogQuery) string {
+				t.Helper()
+				got, err := collect(store.Entries(q))
+				if err != nil {
+					t.Fatalf("Entries() error = %v", err)
+				}
+				result := ""
+				for _, entry := range got {
+					result += entry.Tag + ";"
+				}
+				return result
+			}
+
+			if got := tags(LogQuery{}); got != "Later;February;January;" {
+				t.Errorf("Entries() = %q, want newest first", got)
+			}
+			if got := tags(LogQuery{Limit: 1}); got != "Later;" {
+				t.Errorf("Entries(limit 1) = %q", got)
+			}
+			if got := tags(LogQuery{From: feb, To: feb.Add(2 * time.Hour)}); got != "February;" {
+				t.Errorf("Entries(range) = %q", got)
+			}
+			if got := tags(LogQuery{Statuses: StatusFilter{Exclude: []string{StatusAbandoned}}}); got != "Later;January;" {
+				t.Errorf("Entries(exclude abandoned) = %q", got)
+			}
+			if got := tags(LogQuery{Projects: ProjectFilter{Project: "acme"}}); got != "January;" {
+				t.Errorf("Entries(project) = %q", got)
+			}
+			if got := tags(LogQuery{Order: OldestFirst, Limit: 2}); got != "January;February;" {
+				t.Errorf("Entries(oldest first, limit 2) = %q", got)
+			}
+			if got := tags(LogQuery{Tags: []string{"february", "later"}}); got != "Later;February;" {
+				t.Errorf("Entries(tags) = %q", got)
+			}
+			if got := tags(LogQuery{Where: func(entry LogEntry) bool { return entry.Status != StatusManual }}); got != "February;January;" {
+				t.Errorf("Entries(where) = %q", got)
+			}
+
+			// Breaking out of the loop stops reading
+			read := 0
+			for _, err := range store.Entries(LogQuery{}) {
+				if err != nil {
+					t.Fatalf("Entries() error = %v", err)
+				}
+				read++
+				break
+			}
+			if read != 1 {
+				t.Errorf("Entries() read %d entries after breaking, want 1", read)
+			}
+
+			// Moving an entry into another month keeps a single copy
+			updated := entries[0]
+			updated.Tag = "Moved"
+			updated.StartTime = feb.Add(-time.Hour)
+			updated.EndTime = feb
+			if err := store.Update(entries[0], updated); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := tags(LogQuery{}); got != "Later;February;Moved;" {
+				t.Errorf("Entries() after update = %q", got)
+			}
+
+			if err := store.Delete(entries[1]); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := store.Delete(entries[1]); err == nil {
+				t.Error("Expected an error deleting an entry twice")
+			}
+			if got := tags(LogQuery{}); got != "Later;Moved;" {
+				t.Errorf("Entries() after delete = %q", got)
+			}
+
+			if _, err := store.GetSession(); !os.IsNotExist(err) {
+				t.Errorf("GetSession() without a session error = %v, want not exist", err)
+			}
+			session := Session{Tag: "Active", StartTime: feb, Project: "acme"}
+			if err := store.PutSession(session); err != nil {
+				t.Fatalf("PutSession() error = %v", err)
+			}
+			got, err := store.GetSession()
+			if err != nil || got.Tag != "Active" || got.Project != "acme" || !got.StartTime.Equal(feb) {
+				t.Errorf("GetSession() = %+v, %v", got, err)
+			}
+			if err := store.DeleteSession(); err != nil {
+				t.Fatalf("DeleteSession() error = %v", err)
+			}
+			if _, err := store.GetSession(); !os.IsNotExist(err) {
+				t.Errorf("GetSession() after delete error = %v, want not exist", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteStoreSelectedInConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	configDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataDir)
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("FLOW_LOG_PATH", "")
+	t.Setenv("FLOW_SESSION_PATH", "")
+	if err := os.MkdirAll(filepath.Join(configDir, "flow"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "flow", "config.yml"), []byte("storage: sqlite\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := LogSession(LogEntry{Tag: "Stored", StartTime: now.Add(-time.Hour), EndTime: now, Duration: time.Hour}); err != nil {
+		t.Fatalf("LogSession() error = %v", err)
+	}
+	if err := SaveSession(Session{Tag: "Active", StartTime: now}); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "flow", "flow.db")); err != nil {
+		t.Errorf("Expected the database to be created: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "flow", "logs")); !os.IsNotExist(err) {
+		t.Errorf("Expected no JSONL logs with sqlite storage, stat error = %v", err)
+	}
+
+	sessions, err := GetRecentSessions(10)
+	if err != nil || len(sessions) != 1 || sessions[0].Tag != "Stored" {
+		t.Errorf("GetRecentSessions() = %+v, %v", sessions, err)
+	}
+	if !SessionExists() {
+		t.Error("Expected the session to exist")
+	}
+	if err := RemoveSession(); err != nil || SessionExists() {
+		t.Errorf("RemoveSession() error = %v, exists = %v", err, SessionExists())
+	}
+}
+
+func TestCopyStore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FLOW_LOG_PATH", "")
+	t.Setenv("FLOW_SESSION_PATH", "")
+
+	open := func(backend string) Store {
+		store, err := OpenStoreBackend(backend)
+		if err != nil {
+			t.Fatalf("OpenStoreBackend(%q) error = %v", backend, err)
+		}
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	}
+	jsonl, sqlite := open(StorageJSONL), open(StorageSQLite)
+
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		entry := LogEntry{ID: NewID(start), Tag: "Work", StartTime: start, EndTime: start.Add(time.Hour), Duration: time.Hour, Status: StatusCompleted}
+		if err := jsonl.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+		start = start.AddDate(0, 0, 15)
+	}
+	if err := jsonl.PutSession(Session{Tag: "Active", StartTime: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CopyStore(jsonl, sqlite)
+	if err != nil {
+		t.Fatalf("CopyStore() error = %v", err)
+	}
+	if result.Copied != 3 || result.Skipped != 0 || !result.Session {
+		t.Errorf("CopyStore() = %+v", result)
+	}
+
+	// Running it again doesn't duplicate anything
+	result, err = CopyStore(jsonl, sqlite)
+	if err != nil || result.Copied != 0 || result.Skipped != 3 {
+		t.Errorf("CopyStore() again = %+v, %v", result, err)
+	}
+
+	copied, err := collect(sqlite.Entries(LogQuery{}))
+	if err != nil || len(copied) != 3 {
+		t.Errorf("Entries() after copy = %d entries, %v", len(copied), err)
+	}
+	if session, err := sqlite.GetSession(); err != nil || session.Tag != "Active" {
+		t.Errorf("GetSession() after copy = %+v, %v", session, err)
+	}
+
+	// Without an active session in the source, the destination has none either
+	if err := jsonl.DeleteSession(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CopyStore(jsonl, sqlite); err != nil {
+		t.Fatalf("CopyStore() error = %v", err)
+	}
+	if _, err := sqlite.GetSession(); !os.IsNotExist(err) {
+		t.Errorf("GetSession() after copying no session error = %v, want not exist", err)
+	}
+}
+
+func TestJSONLEntriesAcrossMonths(t *testing.T) {
+	store := openTestStore(t, StorageJSONL)
+	logDir, err := GetLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry logged in another time zone can be filed in the neighbouring
+	// month, so it has to be ordered against that month's entries too
+	files := map[string]string{
+		"202501_sessions.jsonl": `{"tag":"January","start_time":"2025-01-31T23:00:00Z","end_time":"2025-01-31T23:45:00Z","duration":2700000000000,"status":"completed"}`,
+		"202502_sessions.jsonl": `{"tag":"Filed in February","start_time":"2025-01-31T23:00:00Z","end_time":"2025-01-31T23:30:00Z","duration":1800000000000,"status":"completed"}` + "\n" +
+			`{"tag":"February","start_time":"2025-02-10T09:00:00Z","end_time":"2025-02-10T10:00:00Z","duration":3600000000000,"status":"completed"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(logDir, name), []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		order Order
+		want  []string
+	}{
+		{NewestFirst, []string{"February", "January", "Filed in February"}},
+		{OldestFirst, []string{"Filed in February", "January", "February"}},
+	} {
+		entries, err := collect(store.Entries(LogQuery{Order: tt.order}))
+		if err != nil {
+			t.Fatalf("Entries() error = %v", err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Tag)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Entries(order %d) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"iter"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Totals is the focus time and number of sessions in a group of entries
+type Totals struct {
+	Duration time.Duration `json:"duration"`
+	Count    int           `json:"count"`
+}
+
+// Summary aggregates log entries: their totals by the day they ended
+// (YYYY-MM-DD), by tag and by project, and their interruptions. Summaries of
+// the monthly log files are kept in an index, so commands that only need
+// totals don't have to read every entry.
+type Summary struct {
+	Total    Totals            `json:"total"`
+	Days     map[string]Totals `json:"days,omitempty"`
+	Tags     map[string]Totals `json:"tags,omitempty"`
+	Projects map[string]Totals `json:"projects,omitempty"`
+	Pauses   PauseSummary      `json:"pauses"`
+}
+
+// PauseSummary aggregates the pauses recorded in log entries
+type PauseSummary struct {
+	Count   int               `json:"count"`
+	Total   time.Duration     `json:"total"`
+	Longest Pause             `json:"longest"`
+	Reasons map[string]Totals `json:"reasons,omitempty"`
+}
+
+// dayKey is the key of the day an entry ended in Summary.Days
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// addTotals adds to a key's totals, dropping the key when nothing is left
+func addTotals(totals *map[string]Totals, key string, duration time.Duration, count int) {
+	if *totals == nil {
+		*totals = make(map[string]Totals)
+	}
+	t := (*totals)[key]
+	t.Duration += duration
+	t.Count += count
+	if t.Count <= 0 {
+		delete(*totals, key)
+		return
+	}
+	(*totals)[key] = t
+}
+
+// add counts an entry in the summary
+func (s *Summary) add(entry LogEntry) {
+	s.count(entry, 1)
+	for _, pause := range entry.Pauses {
+		s.Pauses.Count++
+		s.Pauses.Total += pause.Duration()
+		if pause.Duration() > s.Pauses.Longest.Duration() {
+			s.Pauses.Longest = pause
+		}
+		if pause.Reason != "" {
+			addTotals(&s.Pauses.Reasons, pause.Reason, pause.Duration(), 1)
+		}
+	}
+}
+
+// remove takes an entry out of the summary. It reports false if the summary
+// can't be updated without the other entries, because the entry held the
+// longest pause.
+func (s *Summary) remove(entry LogEntry) bool {
+	for _, pause := range entry.Pauses {
+		if pause.Duration() > 0 && pause.Duration() == s.Pauses.Longest.Duration() {
+			return false
+		}
+	}
+	s.count(entry, -1)
+	for _, pause := range entry.Pauses {
+		s.Pauses.Count--
+		s.Pauses.Total -= pause.Duration()
+		if pause.Reason != "" {
+			addTotals(&s.Pauses.Reasons, pause.Reason, -pause.Duration(), -1)
+		}
+	}
+	return true
+}
+
+// count adds an entry's duration to its day, tag and project n times
+func (s *Summary) count(entry LogEntry, n int) {
+	duration := time.Duration(n) * entry.Duration
+	s.Total.Duration += duration
+	s.Total.Count += n
+	addTotals(&s.Days, dayKey(entry.EndTime), duration, n)
+	addTotals(&s.Tags, entry.Tag, duration, n)
+	if entry.Project != "" {
+		addTotals(&s.Projects, entry.Project, duration, n)
+	}
+}
+
+// merge adds another summary's totals to this one
+func (s *Summary) merge(other *Summary) {
+	s.Total.Duration += other.Total.Duration
+	s.Total.Count += other.Total.Count
+	for day, t := range other.Days {
+		addTotals(&s.Days, day, t.Duration, t.Count)
+	}
+	for tag, t := range other.Tags {
+		addTotals(&s.Tags, tag, t.Duration, t.Count)
+	}
+	for project, t := range other.Projects {
+		addTotals(&s.Projects, project, t.Duration, t.Count)
+	}
+	s.Pauses.Count += other.Pauses.Count
+	s.Pauses.Total += other.Pauses.Total
+	if other.Pauses.Longest.Duration() > s.Pauses.Longest.Duration() {
+		s.Pauses.Longest = other.Pauses.Longest
+	}
+	for reason, t := range other.Pauses.Reasons {
+		addTotals(&s.Pauses.Reasons, reason, t.Duration, t.Count)
+	}
+}
+
+// DailyTotals returns the focus time per day, keyed by midnight UTC of the
+// day the sessions ended
+func (s *Summary) DailyTotals() map[time.Time]time.Duration {
+	totals := make(map[time.Time]time.Duration, len(s.Days))
+	for key, t := range s.Days {
+		day, err := time.Parse("2006-01-02", key)
+		if err != nil {
+			continue
+		}
+		totals[day] += t.Duration
+	}
+	return totals
+}
+
+// Interruptions returns the interruption statistics of the summarised entries
+func (s *Summary) Interruptions() InterruptionStats {
+	stats := InterruptionStats{
+		Count:     s.Pauses.Count,
+		TotalTime: s.Pauses.Total,
+		Longest:   s.Pauses.Longest,
+	}
+	for reason, t := range s.Pauses.Reasons {
+		stats.TopReasons = append(stats.TopReasons, ReasonStat{Reason: reason, Count: t.Count, Duration: t.Duration})
+	}
+
+	// Most frequent first, then longest
+	sort.Slice(stats.TopReasons, func(i, j int) bool {
+		if stats.TopReasons[i].Count != stats.TopReasons[j].Count {
+			return stats.TopReasons[i].Count > stats.TopReasons[j].Count
+		}
+		return stats.TopReasons[i].Duration > stats.TopReasons[j].Duration
+	})
+	return stats
+}
+
+// ProjectRollup totals time per project at every level of the hierarchy, as
+// CalculateProjectRollup does for a list of entries
+func (s *Summary) ProjectRollup() []ProjectStat {
+	totals := make(map[string]*ProjectStat)
+	for project, t := range s.Projects {
+		segments := strings.Split(project, "/")
+		for depth := range segments {
+			path := strings.Join(segments[:depth+1], "/")
+			stat, ok := totals[path]
+			if !ok {
+				stat = &ProjectStat{Project: path, Depth: depth}
+				totals[path] = stat
+			}
+			stat.Duration += t.Duration
+			stat.Count += t.Count
+		}
+	}
+	return sortProjectStats(totals)
+}
+
+// summarize aggregates the entries from an iterator
+func summarize(entries iter.Seq2[LogEntry, error]) (*Summary, error) {
+	summary := &Summary{}
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+		summary.add(entry)
+	}
+	return summary, nil
+}
+
+// indexable reports whether a query only selects entries by time and status,
+// which is all the summary index records
+func (q LogQuery) indexable() bool {
+	return q.Limit == 0 && q.Projects.Project == "" && len(q.Projects.Labels) == 0 &&
+		q.Repos == (RepoFilter{}) && len(q.Tags) == 0 && q.Where == nil
+}
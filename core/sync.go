@@ -0,0 +1,172 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncConfig turns on sync mode, for keeping the data directory in a folder
+// shared between devices by a tool like Syncthing or Dropbox. Each device
+// then writes only its own log and session files, so the tool never has two
+// versions of a file to reconcile. Only the JSONL backend supports it.
+type SyncConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Device names this device's files; it defaults to the host name
+	Device string `yaml:"device"`
+}
+
+// DeviceSession is a session running on another device
+type DeviceSession struct {
+	Device  string
+	Session Session
+}
+
+// DeviceID returns the name this device's files go by: the configured device
+// or the host name, in lowercase letters, digits and dashes
+func (c SyncConfig) DeviceID() string {
+	name := c.Device
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	id := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name), "-")
+	if id == "" {
+		return "device"
+	}
+	return id
+}
+
+// DeviceID returns the name of this device
+func DeviceID() string {
+	config, _ := LoadConfig()
+	return config.Sync.DeviceID()
+}
+
+// syncDevice returns this device's name when sync mode is on, and "" when it
+// is off. A config that can't be read leaves sync mode off.
+func syncDevice() string {
+	config, err := LoadConfig()
+	if err != nil || !config.Sync.Enabled || (config.Storage != "" && config.Storage != StorageJSONL) {
+		return ""
+	}
+	return config.Sync.DeviceID()
+}
+
+// validDevice reports whether a name can be a device's name
+func validDevice(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// deviceLogPath returns a device's file for the month of a monthly log file
+func deviceLogPath(logPath, device string) string {
+	return strings.TrimSuffix(logPath, ".jsonl") + "." + device + ".jsonl"
+}
+
+// fileDevice returns the device whose file a log file is in sync mode, and ""
+// for a file every device shares
+func fileDevice(path string) string {
+	stem := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), archiveSuffix), ".jsonl")
+	i := strings.LastIndex(stem, ".")
+	if i < 0 || strings.Contains(stem[:i], ".") || !validDevice(stem[i+1:]) {
+		return ""
+	}
+	return stem[i+1:]
+}
+
+// otherDevices reports whether a log file belongs to a device other than the
+// given one, which in sync mode leaves changing it to that device
+func otherDevices(path, device string) bool {
+	owner := fileDevice(path)
+	return device != "" && owner != "" && owner != device
+}
+
+// deviceFiles returns the files matching the pattern of a shared log file
+// along with each device's version of them. Copies a sync tool made of a
+// conflicting file don't follow the naming scheme and are left out.
+func deviceFiles(pattern string) ([]string, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	stem := strings.TrimSuffix(pattern, ".jsonl")
+	devices, err := filepath.Glob(stem + ".*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range devices {
+		if device := fileDevice(file); device != "" {
+			if shared, _ := filepath.Match(stem, strings.TrimSuffix(file, "."+device+".jsonl")); shared {
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// getDeviceSessionDir returns the directory holding each device's session
+// file in sync mode
+func getDeviceSessionDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "sessions"), nil
+}
+
+// OtherDeviceSessions returns the sessions running on the other devices that
+// share the data directory in sync mode, by device name
+func OtherDeviceSessions() ([]DeviceSession, error) {
+	device := syncDevice()
+	if device == "" {
+		return nil, nil
+	}
+	dir, err := getDeviceSessionDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []DeviceSession
+	for _, file := range files {
+		name := file.Name()
+		if name == device || !validDevice(name) || !file.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+		sessions = append(sessions, DeviceSession{Device: name, Session: session})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Device < sessions[j].Device })
+	return sessions, nil
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncMode(t *testing.T) {
+	openTestStore(t, StorageJSONL)
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useDevice := func(device string) {
+		t.Helper()
+		config := "sync:\n  enabled: true\n  device: " + device + "\n"
+		if device == "" {
+			config = ""
+		}
+		if err := ensureDir(configPath); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logged := func(day int, tag string) LogEntry {
+		t.Helper()
+		end := time.Date(2025, 3, day, 10, 0, 0, 0, time.Local)
+		entry := LogEntry{ID: NewID(end), Tag: tag, StartTime: end.Add(-time.Hour), EndTime: end, Duration: time.Hour, Status: StatusCompleted}
+		if err := LogSession(entry); err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	logDir, err := GetLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(logDir, name))
+		return err == nil
+	}
+
+	// Sessions logged before sync mode stay in the shared file
+	useDevice("")
+	logged(2, "Before sync")
+	useDevice("desktop")
+	desktop := logged(3, "Desktop")
+	if err := SaveSession(Session{Tag: "Desktop work", StartTime: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	useDevice("laptop")
+	logged(4, "Laptop")
+	if !exists("202503_sessions.jsonl") || !exists("202503_sessions.desktop.jsonl") || !exists("202503_sessions.laptop.jsonl") {
+		t.Fatal("Expected each device to log to its own file")
+	}
+
+	// A copy left by the sync tool is not read
+	conflict := filepath.Join(logDir, "202503_sessions.laptop.sync-conflict-20250304.jsonl")
+	if err := os.WriteFile(conflict, []byte(`{"tag":"Conflict","end_time":"2025-03-04T10:00:00Z"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := func() string {
+		t.Helper()
+		reader, err := NewLogReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := reader.Query(LogQuery{Order: OldestFirst})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := ""
+		for _, entry := range entries {
+			result += entry.Tag + ";"
+		}
+		return result
+	}
+	if got := tags(); got != "Before sync;Desktop;Laptop;" {
+		t.Errorf("Query() in sync mode = %q", got)
+	}
+
+	// Each device has its own session, and sees the others'
+	if SessionExists() {
+		t.Error("Expected the laptop to have no session of its own")
+	}
+	others, err := OtherDeviceSessions()
+	if err != nil || len(others) != 1 || others[0].Device != "desktop" || others[0].Session.Tag != "Desktop work" || others[0].Session.Device != "desktop" {
+		t.Errorf("OtherDeviceSessions() = %+v, %v", others, err)
+	}
+
+	// Cancelled sessions and breaks go to each device's own files too
+	start := time.Date(2025, 3, 4, 11, 0, 0, 0, time.Local)
+	cancel := func(tag string) {
+		t.Helper()
+		session := Session{Tag: tag, StartTime: start}
+		if err := SaveSession(session); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CancelSession(session, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel("Laptop cancel")
+	if err := LogBreak(LogEntry{Tag: "Laptop", StartTime: start, EndTime: start.Add(5 * time.Minute), Phase: PhaseBreak}); err != nil {
+		t.Fatal(err)
+	}
+	useDevice("desktop")
+	cancel("Desktop cancel")
+	useDevice("laptop")
+	if exists("cancelled.jsonl") || !exists("cancelled.laptop.jsonl") || !exists("cancelled.desktop.jsonl") ||
+		exists("202503_breaks.jsonl") || !exists("202503_breaks.laptop.jsonl") {
+		t.Error("Expected each device to keep its own cancel and break logs")
+	}
+	cancelled, err := ReadCancelledEntries()
+	if err != nil || len(cancelled) != 2 {
+		t.Errorf("ReadCancelledEntries() = %+v, %v", cancelled, err)
+	}
+
+	// Only the device that logged an entry changes its file
+	if err := DeleteLogEntry(desktop); err == nil {
+		t.Error("DeleteLogEntry() of another device's entry should fail")
+	}
+	edited := desktop
+	edited.Tag = "Edited"
+	if _, err := EditLogEntry(desktop, edited); err == nil {
+		t.Error("EditLogEntry() of another device's entry should fail")
+	}
+	desktopFile := filepath.Join(logDir, "202503_sessions.desktop.jsonl")
+	data, err := os.ReadFile(desktopFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A copy doctor would remove from the laptop's own file
+	if err := os.WriteFile(desktopFile, append(data, data...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := CheckData(true)
+	if err != nil || len(report.Problems) != 1 || report.Problems[0].Kind != ProblemDuplicate || report.Problems[0].Fixed {
+		t.Errorf("CheckData(fix) of another device's file = %+v, %v", report.Problems, err)
+	}
+	if after, _ := os.ReadFile(desktopFile); string(after) != string(data)+string(data) {
+		t.Error("Expected doctor to leave another device's file alone")
+	}
+	if err := os.WriteFile(desktopFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := tags(); got != "Before sync;Desktop;Laptop;" {
+		t.Errorf("Query() after refused changes = %q", got)
+	}
+
+	// Each device archives only its own files
+	if _, err := ArchiveLogs(time.Date(2025, 5, 1, 0, 0, 0, 0, time.Local), time.Time{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("202503_sessions.laptop.jsonl.gz") || !exists("202503_sessions.desktop.jsonl") || !exists("202503_sessions.jsonl") {
+		t.Error("Expected only the laptop's file to be archived")
+	}
+	if got := tags(); got != "Before sync;Desktop;Laptop;" {
+		t.Errorf("Query() after archiving = %q", got)
+	}
+}
+
+func TestDeviceID(t *testing.T) {
+	for device, want := range map[string]string{
+		"laptop":             "laptop",
+		"Jo's MacBook.local": "jo-s-macbook-local",
+		"--":                 "device",
+		"Work-Desktop-2":     "work-desktop-2",
+	} {
+		if got := (SyncConfig{Device: device}).DeviceID(); got != want {
+			t.Errorf("DeviceID(%q) = %q, want %q", device, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockLayouts are the time-of-day formats accepted on the command line
+var clockLayouts = []string{"15:04", "15:04:05"}
+
+// dateTimeLayouts are the full date and time formats accepted on the command line
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+}
+
+// ParseClockTime parses a user supplied point in time. A bare time of day such
+// as "09:30" is taken to be on the same day as ref; full dates are accepted in
+// RFC 3339 or "YYYY-MM-DD HH:MM" form and are interpreted in ref's location.
+func ParseClockTime(value string, ref time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if t, ok := parseTimeOfDay(value, ref); ok {
+		return t, nil
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, ref.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (use HH:MM or YYYY-MM-DD HH:MM)", value)
+}
+
+// ParsePastTime parses a user supplied point in the past. It accepts the forms
+// understood by ParseClockTime, where a time of day later than now refers to
+// yesterday, or a duration such as "20m" meaning that long ago.
+func ParsePastTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+
+	if t, ok := parseTimeOfDay(value, now); ok {
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+
+	t, err := ParseClockTime(value, now)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q (use HH:MM, YYYY-MM-DD HH:MM or a duration like '20m')", value)
+	}
+	return t, nil
+}
+
+// parseTimeOfDay parses a bare time of day on the same day as ref
+func parseTimeOfDay(value string, ref time.Time) (time.Time, bool) {
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, value, ref.Location()); err == nil {
+			return time.Date(ref.Year(), ref.Month(), ref.Day(),
+				t.Hour(), t.Minute(), t.Second(), 0, ref.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
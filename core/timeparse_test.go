@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClockTime(t *testing.T) {
+	ref := time.Date(2024, 5, 6, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "09:15", want: time.Date(2024, 5, 6, 9, 15, 0, 0, time.UTC)},
+		{value: "09:15:30", want: time.Date(2024, 5, 6, 9, 15, 30, 0, time.UTC)},
+		{value: "2024-04-30 23:00", want: time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC)},
+		{value: "2024-04-30T23:00:00Z", want: time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC)},
+		{value: "tomorrow-ish", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseClockTime(tt.value, ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseClockTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("ParseClockTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParsePastTime(t *testing.T) {
+	now := time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "07:30", want: time.Date(2024, 5, 6, 7, 30, 0, 0, time.UTC)},
+		{value: "23:00", want: time.Date(2024, 5, 5, 23, 0, 0, 0, time.UTC)}, // not yet today
+		{value: "20m", want: now.Add(-20 * time.Minute)},
+		{value: "2024-05-01 12:00", want: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePastTime(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePastTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("ParsePastTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
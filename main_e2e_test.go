@@ -2,12 +2,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -303,3 +305,252 @@ func TestE2EDataCommands(t *testing.T) {
 		}
 	})
 }
+
+func TestE2ESwitch(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	// Record the order in which hooks fire
+	hooksDir := filepath.Join(tempDir, "flow", "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatalf("Failed to create hooks directory: %v", err)
+	}
+	hookOutputPath := filepath.Join(tempDir, "hook_output.txt")
+	for _, event := range []string{"on_start", "on_end"} {
+		script := fmt.Sprintf("#!/bin/sh\necho \"%s $1\" >> %s\n", event, hookOutputPath)
+		if err := os.WriteFile(filepath.Join(hooksDir, event), []byte(script), 0755); err != nil {
+			t.Fatalf("Failed to write hook script: %v", err)
+		}
+	}
+
+	if _, stderr, err := runFlowCommand(t, "start", "--tag", "first task", "--target", "1h"); err != nil {
+		t.Fatalf("Failed to start session: %v\nStderr: %s", err, stderr)
+	}
+
+	stdout, stderr, err := runFlowCommand(t, "switch", "--tag", "second task", "--keep-target")
+	if err != nil {
+		t.Fatalf("Expected 'switch' to succeed, but got error: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Session complete: first task") || !strings.Contains(stdout, "Switched to: second task") {
+		t.Errorf("Unexpected switch output:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "Target: 1h 0m") {
+		t.Errorf("Expected the target to be kept, got:\n%s", stdout)
+	}
+
+	stdout, _, _ = runFlowCommand(t, "status", "--raw")
+	if stdout != "second task" {
+		t.Errorf("Expected 'second task' to be active, got %q", stdout)
+	}
+
+	hookOutput, err := os.ReadFile(hookOutputPath)
+	if err != nil {
+		t.Fatalf("Failed to read hook output: %v", err)
+	}
+	expected := "on_start first task\non_end first task\non_start second task\n"
+	if string(hookOutput) != expected {
+		t.Errorf("Expected hooks to fire as %q, got %q", expected, string(hookOutput))
+	}
+
+	// The logged session ends exactly when the new one starts
+	sessionData, err := os.ReadFile(filepath.Join(tempDir, "flow", "session"))
+	if err != nil {
+		t.Fatalf("Failed to read session file: %v", err)
+	}
+	logFiles, _ := filepath.Glob(filepath.Join(tempDir, "flow", "logs", "*_sessions.jsonl"))
+	if len(logFiles) != 1 {
+		t.Fatalf("Expected one log file, got %v", logFiles)
+	}
+	logData, _ := os.ReadFile(logFiles[0])
+	var session struct {
+		StartTime time.Time `json:"start_time"`
+	}
+	var entry struct {
+		EndTime time.Time `json:"end_time"`
+	}
+	if err := json.Unmarshal(sessionData, &session); err != nil {
+		t.Fatalf("Failed to parse session: %v", err)
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(logData))), &entry); err != nil {
+		t.Fatalf("Failed to parse log entry: %v", err)
+	}
+	if !entry.EndTime.Equal(session.StartTime) {
+		t.Errorf("Expected logged end %v to equal new start %v", entry.EndTime, session.StartTime)
+	}
+}
+
+func TestE2EConcurrentCommands(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	if _, stderr, err := runFlowCommand(t, "start", "--tag", "racing"); err != nil {
+		t.Fatalf("Failed to start session: %v\nStderr: %s", err, stderr)
+	}
+
+	// Hammer the session from several processes at once, as shell prompts and
+	// editor integrations do, then end it from several more.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for _, command := range []string{"pause", "resume", "status"} {
+			wg.Add(1)
+			go func(command string) {
+				defer wg.Done()
+				if _, stderr, err := runFlowCommand(t, command); err != nil {
+					t.Errorf("'%s' failed: %v\nStderr: %s", command, err, stderr)
+				}
+			}(command)
+		}
+	}
+	wg.Wait()
+
+	if _, err := os.ReadFile(filepath.Join(tempDir, "flow", "session")); err != nil {
+		t.Fatalf("Expected the session file to survive, got %v", err)
+	}
+	stdout, _, _ := runFlowCommand(t, "status", "--raw")
+	if stdout != "racing" {
+		t.Fatalf("Expected the session to be intact, got %q", stdout)
+	}
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = runFlowCommand(t, "end")
+		}()
+	}
+	wg.Wait()
+
+	logFiles, _ := filepath.Glob(filepath.Join(tempDir, "flow", "logs", "*_sessions.jsonl"))
+	if len(logFiles) != 1 {
+		t.Fatalf("Expected one log file, got %v", logFiles)
+	}
+	logData, _ := os.ReadFile(logFiles[0])
+	lines := strings.Split(strings.TrimSpace(string(logData)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected the session to be logged exactly once, got %d lines:\n%s", len(lines), logData)
+	}
+	var entry struct {
+		Tag string `json:"tag"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil || entry.Tag != "racing" {
+		t.Errorf("Expected an intact log entry, got %q (err %v)", lines[0], err)
+	}
+}
+
+func TestE2EInterruptAndReturn(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	if _, stderr, err := runFlowCommand(t, "start", "--tag", "feature work"); err != nil {
+		t.Fatalf("Failed to start session: %v\nStderr: %s", err, stderr)
+	}
+
+	stdout, stderr, err := runFlowCommand(t, "interrupt", "--tag", "prod incident")
+	if err != nil {
+		t.Fatalf("Expected 'interrupt' to succeed, but got error: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "On hold: feature work") {
+		t.Errorf("Unexpected interrupt output:\n%s", stdout)
+	}
+
+	stdout, _, _ = runFlowCommand(t, "status")
+	if !strings.Contains(stdout, "prod incident") || !strings.Contains(stdout, "on hold: feature work") {
+		t.Errorf("Expected status to show the nesting, got:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runFlowCommand(t, "return")
+	if err != nil {
+		t.Fatalf("Expected 'return' to succeed, but got error: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Interruption complete: prod incident") || !strings.Contains(stdout, "Back to: feature work") {
+		t.Errorf("Unexpected return output:\n%s", stdout)
+	}
+
+	stdout, _, _ = runFlowCommand(t, "status", "--raw")
+	if stdout != "feature work" {
+		t.Errorf("Expected 'feature work' to be active again, got %q", stdout)
+	}
+
+	stdout, _, _ = runFlowCommand(t, "return")
+	if !strings.Contains(stdout, "didn't interrupt another session") {
+		t.Errorf("Expected 'return' without a stack to refuse, got:\n%s", stdout)
+	}
+
+	if _, stderr, err := runFlowCommand(t, "end"); err != nil {
+		t.Fatalf("Failed to end session: %v\nStderr: %s", err, stderr)
+	}
+	logFiles, _ := filepath.Glob(filepath.Join(tempDir, "flow", "logs", "*_sessions.jsonl"))
+	if len(logFiles) != 1 {
+		t.Fatalf("Expected one log file, got %v", logFiles)
+	}
+	logData, _ := os.ReadFile(logFiles[0])
+	if lines := strings.Split(strings.TrimSpace(string(logData)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected both sessions to be logged, got:\n%s", logData)
+	}
+}
+
+func TestE2EStaleRecovery(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("XDG_DATA_HOME", tempDir)
+
+	writeStaleSession := func(tag string) {
+		start := time.Now().Add(-20 * time.Hour)
+		session := fmt.Sprintf(`{"tag":%q,"start_time":%q,"is_paused":false,"total_paused":0,"last_activity":%q}`,
+			tag, start.Format(time.RFC3339), start.Add(2*time.Hour).Format(time.RFC3339))
+		if err := os.MkdirAll(filepath.Join(tempDir, "flow"), 0755); err != nil {
+			t.Fatalf("Failed to create data dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, "flow", "session"), []byte(session), 0644); err != nil {
+			t.Fatalf("Failed to write session file: %v", err)
+		}
+	}
+	readLog := func() string {
+		logFiles, _ := filepath.Glob(filepath.Join(tempDir, "flow", "logs", "*_sessions.jsonl"))
+		var data []byte
+		for _, logFile := range logFiles {
+			content, _ := os.ReadFile(logFile)
+			data = append(data, content...)
+		}
+		return string(data)
+	}
+
+	// Recover keeps the session up to its last heartbeat
+	writeStaleSession("forgotten")
+	cmd := exec.Command(binaryPath, "recover")
+	cmd.Stdin = strings.NewReader("2\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Expected 'recover' to succeed, but got error: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(string(output), "Logged session: forgotten (2h 0m)") {
+		t.Errorf("Unexpected recover output:\n%s", output)
+	}
+	if log := readLog(); !strings.Contains(log, `"duration":7200000000000`) {
+		t.Errorf("Expected 2h to be logged, got:\n%s", log)
+	}
+
+	// With stale_policy: discard, start drops it without logging
+	configPath := filepath.Join(tempDir, "flow", "config.yml")
+	if err := os.WriteFile(configPath, []byte("stale_policy: discard\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	writeStaleSession("dropped")
+	stdout, stderr, err := runFlowCommand(t, "start", "--tag", "fresh")
+	if err != nil {
+		t.Fatalf("Expected 'start' to succeed, but got error: %v\nStderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Discarded it without logging") || !strings.Contains(stdout, "Starting deep work: fresh") {
+		t.Errorf("Unexpected start output:\n%s", stdout)
+	}
+	if log := readLog(); strings.Contains(log, "dropped") {
+		t.Errorf("Expected the stale session not to be logged, got:\n%s", log)
+	}
+}